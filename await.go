@@ -0,0 +1,114 @@
+package dix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jbterrylin/dix/internal/ditag"
+)
+
+// Await returns the value of T under key, waiting until it is added if it is not registered yet.
+// Once ctx ends, the returned error matches both ErrValueNotFound and the ctx error.
+func Await[T any](ctx context.Context, key ValueKey) (T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	err := await(ctx, func(added AfterAddCtx) bool {
+		return added.Type == t && added.ValueKey != nil
+	}, func() bool {
+		return Has[T](key)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return GetByKey[T](key)
+}
+
+// AwaitProvider returns the value of the provider of T under key like GetProviderByKeyWithCtx,
+// waiting until the provider is added if it is not registered yet.
+// Errors of its factory function are returned as is, even if a dependency of it is not found.
+func AwaitProvider[T any](ctx context.Context, key ProviderKey, opts ...ProviderGetOption) (T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	err := await(ctx, func(added AfterAddCtx) bool {
+		return added.Type == t && added.ProviderKey != nil
+	}, func() bool {
+		return HasProvider[T](key)
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return GetProviderByKeyWithCtx[T](ctx, key, opts...)
+}
+
+// await waits until found reports true, checking again after each addition matching match.
+func await(ctx context.Context, match func(added AfterAddCtx) bool, found func() bool) error {
+	// subscribe first, so no addition is missed between found and waiting
+	added := make(chan struct{}, 1)
+	unsubscribe := Container.afterAdd.addInternal(func(ctx AfterAddCtx) {
+		if !match(ctx) {
+			return
+		}
+		select {
+		case added <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	for !found() {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrValueNotFound, ctx.Err())
+		case <-added:
+		}
+	}
+	return nil
+}
+
+// awaitInjectTag resolves tag like resolveInjectTag, again after each addition of any type,
+// since a config leaf or fallback key may depend on another type.
+func awaitInjectTag(ctx context.Context, typ reflect.Type, tag injectTag) (*reflect.Value, error) {
+	var tmp *reflect.Value
+	var err error
+	awaitErr := await(ctx, func(AfterAddCtx) bool {
+		return true
+	}, func() bool {
+		tmp, err = resolveInjectTag(ctx, typ, tag)
+		// a provider found but failing is not waited for
+		return !errors.Is(err, ErrValueNotFound) || hasInjectTagProvider(typ, tag)
+	})
+	if awaitErr != nil {
+		return nil, awaitErr
+	}
+	return tmp, err
+}
+
+// hasInjectTagProvider reports whether a provider tag finds any of its providers, like getFromProvider does.
+func hasInjectTagProvider(typ reflect.Type, tag injectTag) bool {
+	if tag.group || tag.config != "" || tag.valType != ditag.TypeProvider {
+		return false
+	}
+
+	keys := []ProviderKey{ProviderKey(tag.key)}
+	if strings.Contains(tag.key, ditag.KeySeparator) {
+		keys = keys[:0]
+		for _, key := range ditag.Keys(tag.key) {
+			providerKey := ProviderKey(key)
+			if key == "" {
+				providerKey = DefaultProviderKey
+			}
+			keys = append(keys, providerKey)
+		}
+	}
+	for _, key := range keys {
+		if _, err := getContainerNestedMapValue(Container.typeKeyProviderMap, typ, key); err == nil {
+			return true
+		}
+	}
+	return false
+}
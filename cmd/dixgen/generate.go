@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jbterrylin/dix/cmd/internal/registry"
+	"github.com/jbterrylin/dix/internal/ditag"
+)
+
+// generator writes the injection functions of struct types of pkg, reporting bindings missing from registry.
+type generator struct {
+	fset     *token.FileSet
+	pkg      *types.Package
+	registry *registry.Registry
+
+	// import path to name
+	imports map[string]string
+	buf     bytes.Buffer
+	diags   []string
+}
+
+func newGenerator(fset *token.FileSet, pkg *types.Package, r *registry.Registry) *generator {
+	return &generator{
+		fset:     fset,
+		pkg:      pkg,
+		registry: r,
+		imports:  make(map[string]string),
+	}
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) diagf(pos token.Pos, format string, args ...any) {
+	g.diags = append(g.diags, fmt.Sprintf("%v: %v", g.fset.Position(pos), fmt.Sprintf(format, args...)))
+}
+
+// importName returns the name path is imported with, adding the import if needed.
+func (g *generator) importName(path string, name string) string {
+	if tmp, exist := g.imports[path]; exist {
+		return tmp
+	}
+
+	used := make(map[string]struct{}, len(g.imports))
+	for _, tmp := range g.imports {
+		used[tmp] = struct{}{}
+	}
+	tmp := name
+	for i := 2; ; i++ {
+		if _, exist := used[tmp]; !exist {
+			break
+		}
+		tmp = name + strconv.Itoa(i)
+	}
+
+	g.imports[path] = tmp
+	return tmp
+}
+
+func (g *generator) dix() string {
+	return g.importName(registry.DixPath, "dix")
+}
+
+func (g *generator) qualifier(pkg *types.Package) string {
+	if pkg == g.pkg {
+		return ""
+	}
+	return g.importName(pkg.Path(), pkg.Name())
+}
+
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+// typeName returns t as reflect prints it, for error messages.
+func typeName(t types.Type) string {
+	return types.TypeString(t, func(pkg *types.Package) string {
+		return pkg.Name()
+	})
+}
+
+// generateType writes dixInject<Name> and dixNew<Name> for the struct type named.
+func (g *generator) generateType(named *types.Named) {
+	name := named.Obj().Name()
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok || named.TypeParams().Len() > 0 {
+		g.diagf(named.Obj().Pos(), "%v: must be a non-generic struct type", name)
+		return
+	}
+
+	ctx := g.importName("context", "context")
+	g.printf("// dixInject%v injects the fields of target like dix.InjectStructWithCtx, without reflection.\n", name)
+	g.printf("func dixInject%v(ctx %v.Context, target *%v) error {\n", name, ctx, name)
+	g.generateStruct(st, "target", "", []types.Type{named})
+	if g.hasPostInject(named) {
+		g.printf("if err := target.PostInject(ctx); err != nil {\n")
+		g.printf("return %v.Errorf(\"failed at post inject, type=%%T: %%w\", target, err)\n", g.importName("fmt", "fmt"))
+		g.printf("}\n")
+	}
+	g.printf("return nil\n}\n\n")
+
+	g.printf("// dixNew%v returns a new %v injected by dixInject%v.\n", name, name, name)
+	g.printf("func dixNew%v(ctx %v.Context) (*%v, error) {\n", name, ctx, name)
+	g.printf("target := &%v{}\n", name)
+	g.printf("if err := dixInject%v(ctx, target); err != nil {\nreturn nil, err\n}\n", name)
+	g.printf("return target, nil\n}\n\n")
+}
+
+func (g *generator) hasPostInject(named *types.Named) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), true, g.pkg, "PostInject")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Params().Len() == 1 && sig.Results().Len() == 1
+}
+
+// generateStruct writes the injection of every field of st, where expr is the expression of the struct.
+// stack holds the inline struct types being generated, to stop on cycles.
+func (g *generator) generateStruct(st *types.Struct, expr string, path string, stack []types.Type) {
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i)).Get(ditag.Name)
+		if tag == ditag.Skip || (field.Embedded() && registry.IsDixNamed(field.Type(), "In")) {
+			continue
+		}
+
+		fieldPath := field.Name()
+		if path != "" {
+			fieldPath = path + "." + field.Name()
+		}
+		fieldExpr := expr + "." + field.Name()
+
+		if token, reason := ditag.Validate(tag); reason != "" {
+			g.diagf(field.Pos(), "%v: invalid tag %q: %v", fieldPath, token, reason)
+			continue
+		}
+		t := ditag.Parse(tag)
+
+		// embedded structs without tag are injected recursively too
+		if t.Inline || (field.Embedded() && tag == "" && isStructOrStructPtr(field.Type())) {
+			g.generateInline(field, fieldExpr, fieldPath, stack)
+			continue
+		}
+
+		if !field.Exported() {
+			g.diagf(field.Pos(), "%v: unexported field cannot be injected", fieldPath)
+			continue
+		}
+
+		switch {
+		case t.Group:
+			g.generateGroup(field, fieldExpr, fieldPath)
+		case t.Config != "":
+			g.generateConfig(field, fieldExpr, fieldPath, t)
+		case t.Type == ditag.TypeProvider:
+			g.generateLookup(registry.Provider, field, fieldExpr, fieldPath, t)
+		default:
+			g.generateLookup(registry.Value, field, fieldExpr, fieldPath, t)
+		}
+	}
+}
+
+func (g *generator) generateInline(field *types.Var, fieldExpr string, fieldPath string, stack []types.Type) {
+	typ := field.Type()
+	ptr, isPtr := typ.(*types.Pointer)
+	if isPtr {
+		typ = ptr.Elem()
+	}
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		g.diagf(field.Pos(), "%v: inline field must be a struct or pointer to struct", fieldPath)
+		return
+	}
+	for _, tmp := range stack {
+		if types.Identical(tmp, typ) {
+			g.diagf(field.Pos(), "%v: inline cycle through %v", fieldPath, typeName(typ))
+			return
+		}
+	}
+
+	if isPtr {
+		g.printf("if %v == nil {\n%v = new(%v)\n}\n", fieldExpr, fieldExpr, g.typeString(typ))
+	}
+	g.generateStruct(st, fieldExpr, fieldPath, append(stack, typ))
+}
+
+func (g *generator) generateGroup(field *types.Var, fieldExpr string, fieldPath string) {
+	var get string
+	switch typ := field.Type().Underlying().(type) {
+	case *types.Slice:
+		get = fmt.Sprintf("%v.GetSetWithCtx[%v](ctx)", g.dix(), g.typeString(typ.Elem()))
+	case *types.Map:
+		if !types.Identical(typ.Key(), types.Typ[types.String]) {
+			g.diagf(field.Pos(), "%v: group map must be keyed by string", fieldPath)
+			return
+		}
+		get = fmt.Sprintf("%v.GetMapWithCtx[%v](ctx)", g.dix(), g.typeString(typ.Elem()))
+	default:
+		g.diagf(field.Pos(), "%v: group field must be a slice or map[string]", fieldPath)
+		return
+	}
+
+	g.printf("{\nv, err := %v\n", get)
+	g.printReturnErr(field, fieldPath)
+	g.printf("%v = v\n}\n", fieldExpr)
+}
+
+// generateConfig injects a config leaf with dix.InjectFuncWithCtx, since its path is only known at runtime.
+func (g *generator) generateConfig(field *types.Var, fieldExpr string, fieldPath string, t ditag.Tag) {
+	if !t.Optional && !t.HasDefault {
+		if !g.registry.HasConfig() {
+			g.diagf(field.Pos(), "%v: no config loaded for path %q", fieldPath, t.Config)
+		}
+	}
+
+	dix := g.dix()
+	opts := []string{fmt.Sprintf("%v.WithInjectFuncConfig(\"0\", %v)", dix, strconv.Quote(t.Config))}
+	if t.Optional {
+		opts = append(opts, fmt.Sprintf("%v.WithInjectFuncOptional(\"0\")", dix))
+	}
+	if t.HasDefault {
+		opts = append(opts, fmt.Sprintf("%v.WithInjectFuncDefault(\"0\", %v)", dix, strconv.Quote(t.Default)))
+	}
+
+	g.printf("if err := %v.InjectFuncWithCtx(ctx, func(v %v) {\n%v = v\n}, %v); err != nil {\n", dix, g.typeString(field.Type()), fieldExpr, strings.Join(opts, ", "))
+	g.printf("return %v.Errorf(\"failed at field name=%v, field type=%v: %%w\", err)\n}\n", g.importName("fmt", "fmt"), fieldPath, typeName(field.Type()))
+}
+
+// generateLookup injects a value or provider, trying fallback keys in order.
+func (g *generator) generateLookup(kind registry.Kind, field *types.Var, fieldExpr string, fieldPath string, t ditag.Tag) {
+	keys := []string{t.Key}
+	if strings.Contains(t.Key, ditag.KeySeparator) {
+		keys = ditag.Keys(t.Key)
+	}
+
+	var defaultLit string
+	if t.HasDefault {
+		lit, err := g.defaultLiteral(field.Type(), t.Default)
+		if err != nil {
+			g.diagf(field.Pos(), "%v: invalid default %q: %v", fieldPath, t.Default, err)
+			return
+		}
+		defaultLit = lit
+	}
+
+	if !t.Optional && !t.HasDefault {
+		g.checkBinding(kind, field, fieldPath, keys)
+	}
+
+	dix := g.dix()
+	errNotFound := fmt.Sprintf("%v.Is(err, %v.ErrValueNotFound)", g.importName("errors", "errors"), dix)
+	for i, key := range keys {
+		if i == 0 {
+			g.printf("{\nv, err := %v\n", g.getExpr(kind, field.Type(), key, t.Reload))
+			continue
+		}
+		g.printf("if %v {\nv, err = %v\n}\n", errNotFound, g.getExpr(kind, field.Type(), key, t.Reload))
+	}
+	if t.HasDefault {
+		g.printf("if %v {\nv, err = %v, nil\n}\n", errNotFound, defaultLit)
+	}
+
+	if t.Optional {
+		g.printf("if err == nil {\n%v = v\n} else if !%v {\n", fieldExpr, errNotFound)
+		g.printf("return %v.Errorf(\"failed at field name=%v, field type=%v: %%w\", err)\n}\n}\n", g.importName("fmt", "fmt"), fieldPath, typeName(field.Type()))
+		return
+	}
+	g.printReturnErr(field, fieldPath)
+	g.printf("%v = v\n}\n", fieldExpr)
+}
+
+func (g *generator) printReturnErr(field *types.Var, fieldPath string) {
+	g.printf("if err != nil {\nreturn %v.Errorf(\"failed at field name=%v, field type=%v: %%w\", err)\n}\n", g.importName("fmt", "fmt"), fieldPath, typeName(field.Type()))
+}
+
+func (g *generator) getExpr(kind registry.Kind, typ types.Type, key string, reload bool) string {
+	dix := g.dix()
+	if kind == registry.Value {
+		keyExpr := dix + ".DefaultValueKey"
+		if key != "" {
+			keyExpr = strconv.Quote(key)
+		}
+		return fmt.Sprintf("%v.GetByKey[%v](%v)", dix, g.typeString(typ), keyExpr)
+	}
+
+	keyExpr := dix + ".DefaultProviderKey"
+	if key != "" {
+		keyExpr = strconv.Quote(key)
+	}
+	if reload {
+		return fmt.Sprintf("%v.GetProviderByKeyWithCtx[%v](ctx, %v, %v.WithProviderReload())", dix, g.typeString(typ), keyExpr, dix)
+	}
+	return fmt.Sprintf("%v.GetProviderByKeyWithCtx[%v](ctx, %v)", dix, g.typeString(typ), keyExpr)
+}
+
+// checkBinding reports a field whose type is registered under none of keys.
+func (g *generator) checkBinding(kind registry.Kind, field *types.Var, fieldPath string, keys []string) {
+	found, mismatch := g.registry.Resolve(kind, field.Type(), keys)
+	if found {
+		return
+	}
+
+	quoted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		quoted = append(quoted, strconv.Quote(key))
+	}
+	if mismatch != nil {
+		g.diagf(field.Pos(), "%v: type mismatch: field type is %v, but %v key %q is registered for %v at %v",
+			fieldPath, typeName(field.Type()), kind, mismatch.Key, typeName(mismatch.Type), mismatch.Pos)
+		return
+	}
+	g.diagf(field.Pos(), "%v: no %v registered for %v with key %v", fieldPath, kind, typeName(field.Type()), strings.Join(quoted, " or "))
+}
+
+// defaultLiteral returns a Go expression of typ for the default literal lit, decoded like dix does at runtime.
+func (g *generator) defaultLiteral(typ types.Type, lit string) (string, error) {
+	if named, ok := typ.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Duration" {
+		d, err := time.ParseDuration(lit)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v(%d)", g.typeString(typ), int64(d)), nil
+	}
+
+	basic, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return "", fmt.Errorf("type %v is not supported by dixgen", typeName(typ))
+	}
+
+	var tmp string
+	info := basic.Info()
+	switch {
+	case info&types.IsString != 0:
+		tmp = strconv.Quote(lit)
+	case info&types.IsBoolean != 0:
+		b, err := strconv.ParseBool(lit)
+		if err != nil {
+			return "", err
+		}
+		tmp = strconv.FormatBool(b)
+	case info&types.IsInteger != 0 && info&types.IsUnsigned != 0:
+		u, err := strconv.ParseUint(lit, 10, basicBits(basic))
+		if err != nil {
+			return "", err
+		}
+		tmp = strconv.FormatUint(u, 10)
+	case info&types.IsInteger != 0:
+		i, err := strconv.ParseInt(lit, 10, basicBits(basic))
+		if err != nil {
+			return "", err
+		}
+		tmp = strconv.FormatInt(i, 10)
+	case info&types.IsFloat != 0:
+		f, err := strconv.ParseFloat(lit, basicBits(basic))
+		if err != nil {
+			return "", err
+		}
+		tmp = strconv.FormatFloat(f, 'g', -1, basicBits(basic))
+	default:
+		return "", fmt.Errorf("type %v is not supported by dixgen", typeName(typ))
+	}
+	return fmt.Sprintf("%v(%v)", g.typeString(typ), tmp), nil
+}
+
+func basicBits(basic *types.Basic) int {
+	switch basic.Kind() {
+	case types.Int8, types.Uint8:
+		return 8
+	case types.Int16, types.Uint16:
+		return 16
+	case types.Int32, types.Uint32, types.Float32:
+		return 32
+	case types.Int64, types.Uint64, types.Float64:
+		return 64
+	}
+	return strconv.IntSize
+}
+
+func isStructOrStructPtr(t types.Type) bool {
+	if ptr, ok := t.(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	_, ok := t.Underlying().(*types.Struct)
+	return ok
+}
+
+// source returns the formatted generated file.
+func (g *generator) source() ([]byte, error) {
+	var file bytes.Buffer
+	fmt.Fprintf(&file, "// Code generated by dixgen. DO NOT EDIT.\n\npackage %v\n\n", g.pkg.Name())
+
+	// standard library first, like goimports
+	var std, others []string
+	for path := range g.imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			others = append(others, path)
+			continue
+		}
+		std = append(std, path)
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+
+	file.WriteString("import (\n")
+	for i, paths := range [][]string{std, others} {
+		if i > 0 && len(std) > 0 && len(others) > 0 {
+			file.WriteString("\n")
+		}
+		for _, path := range paths {
+			name := g.imports[path]
+			if name == path[strings.LastIndex(path, "/")+1:] {
+				fmt.Fprintf(&file, "%q\n", path)
+				continue
+			}
+			fmt.Fprintf(&file, "%v %q\n", name, path)
+		}
+	}
+	file.WriteString(")\n\n")
+	file.Write(g.buf.Bytes())
+
+	return format.Source(file.Bytes())
+}
@@ -0,0 +1,136 @@
+// Command dixgen generates functions injecting `di` tagged structs with typed dix calls instead of reflection.
+// Missing bindings and type mismatches are reported at generation time.
+//
+// It reads the `di` tags of the given types, and the dix registration calls (Add, AddProvider,
+// AddCtxProvider, AddStruct, Provide, LoadConfig) of every loaded package, then writes
+// dixInject<Type>(ctx, target) and dixNew<Type>(ctx) into the package of the types.
+//
+// Usage:
+//
+//	//go:generate go run github.com/jbterrylin/dix/cmd/dixgen -type Service,Handler . ./cmd/app
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/jbterrylin/dix/cmd/internal/registry"
+)
+
+const defaultOutput = "dix_gen.go"
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct type names; required")
+	output := flag.String("output", defaultOutput, "output file name, relative to the package of the types")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: dixgen -type T[,T...] [-output file] [packages]\n")
+		fmt.Fprintf(os.Stderr, "Packages default to \".\". Registrations are read from every package given.\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	if err := run("", patterns, strings.Split(*typeNames, ","), *output, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "dixgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+var errGenerate = errors.New("generation failed")
+
+// run loads patterns from dir, then writes the generated file of typeNames to output.
+// Diagnostics are written to w, and errGenerate is returned if there is any.
+func run(dir string, patterns []string, typeNames []string, output string, w io.Writer) error {
+	src, pkgDir, diags, err := generate(dir, patterns, typeNames)
+	if err != nil {
+		return err
+	}
+	if len(diags) > 0 {
+		for _, diag := range diags {
+			fmt.Fprintln(w, diag)
+		}
+		return errGenerate
+	}
+
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(pkgDir, output)
+	}
+	return os.WriteFile(output, src, 0o644)
+}
+
+// generate returns the generated source, and the directory of the package of typeNames.
+func generate(dir string, patterns []string, typeNames []string) (src []byte, pkgDir string, diags []string, err error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps |
+			packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo,
+		Dir: dir,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if packages.PrintErrors(pkgs) > 0 {
+		return nil, "", nil, errors.New("failed to load packages")
+	}
+
+	// every type must be in the same package
+	var (
+		target *packages.Package
+		named  []*types.Named
+	)
+	for _, name := range typeNames {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, pkg := range pkgs {
+			obj, ok := pkg.Types.Scope().Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			if target != nil && target != pkg {
+				return nil, "", nil, fmt.Errorf("type %v is not in package %v", name, target.PkgPath)
+			}
+			tmp, ok := obj.Type().(*types.Named)
+			if !ok {
+				return nil, "", nil, fmt.Errorf("type %v is an alias", name)
+			}
+			target = pkg
+			named = append(named, tmp)
+			found = true
+			break
+		}
+		if !found {
+			return nil, "", nil, fmt.Errorf("type %v not found", name)
+		}
+	}
+
+	g := newGenerator(target.Fset, target.Types, registry.Scan(pkgs))
+	for _, tmp := range named {
+		g.generateType(tmp)
+	}
+	if len(g.diags) > 0 {
+		return nil, "", g.diags, nil
+	}
+
+	src, err = g.source()
+	if err != nil {
+		return nil, "", nil, err
+	}
+	return src, filepath.Dir(target.GoFiles[0]), nil, nil
+}
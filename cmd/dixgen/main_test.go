@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		typeNames []string
+	}{
+		{"basic", []string{"Service", "Repo"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join("testdata", tt.name)
+			src, _, diags, err := generate(dir, []string{"."}, tt.typeNames)
+			if err != nil || len(diags) > 0 {
+				t.Fatalf("unexpected generate() err: got %v %v, want %v", err, diags, nil)
+			}
+
+			golden := filepath.Join(dir, defaultOutput+".golden")
+			if *update {
+				if err := os.WriteFile(golden, src, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(src, want) {
+				t.Errorf("unexpected generate() src:\n%s\nwant:\n%s", src, want)
+			}
+
+			// the generated file must compile with its package
+			absDir, _ := filepath.Abs(dir)
+			cfg := &packages.Config{
+				Mode:    packages.NeedName | packages.NeedTypes | packages.NeedImports | packages.NeedDeps,
+				Dir:     dir,
+				Overlay: map[string][]byte{filepath.Join(absDir, defaultOutput): src},
+			}
+			pkgs, err := packages.Load(cfg, ".")
+			if err != nil {
+				t.Fatal(err)
+			}
+			packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+				for _, err := range pkg.Errors {
+					t.Errorf("unexpected generated src err: %v", err)
+				}
+			})
+		})
+	}
+}
+
+func TestGenerateDiagnostics(t *testing.T) {
+	dir := filepath.Join("testdata", "invalid")
+
+	var buf bytes.Buffer
+	err := run(dir, []string{"."}, []string{"Service"}, filepath.Join(t.TempDir(), defaultOutput), &buf)
+	if !errors.Is(err, errGenerate) {
+		t.Fatalf("unexpected run() err: got %v, want %v", err, errGenerate)
+	}
+
+	// make positions independent of the checkout
+	absDir, _ := filepath.Abs(dir)
+	got := strings.ReplaceAll(buf.String(), absDir+string(filepath.Separator), "")
+
+	golden := filepath.Join(dir, "errors.golden")
+	if *update {
+		if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("unexpected run() diagnostics:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestGenerateTypeNotFound(t *testing.T) {
+	_, _, _, err := generate(filepath.Join("testdata", "basic"), []string{"."}, []string{"Unknown"})
+	if err == nil {
+		t.Errorf("unexpected generate() err: got %v, want an error", err)
+	}
+}
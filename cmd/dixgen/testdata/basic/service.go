@@ -0,0 +1,52 @@
+package basic
+
+import (
+	"context"
+	"time"
+
+	"github.com/jbterrylin/dix"
+)
+
+type Store struct {
+	Name string
+}
+
+type Handler interface {
+	Handle()
+}
+
+type Config struct {
+	Server struct {
+		Addr string
+	}
+}
+
+type Repo struct {
+	Store *Store `di:"key:main"`
+}
+
+type Embedded struct {
+	Replica *Store `di:"key:replica|main|"`
+}
+
+type Service struct {
+	dix.In
+
+	Store    *Store             `di:"key:main"`
+	Cache    *Store             `di:"type:provider;key:cache;reload"`
+	Timeout  time.Duration      `di:"key:timeout;default:30s"`
+	Retries  int                `di:"key:retries;optional"`
+	Handlers []Handler          `di:"group"`
+	Named    map[string]Handler `di:"group"`
+	Addr     string             `di:"config:server.addr"`
+	Repo     *Repo              `di:"inline"`
+	Embedded
+	Skipped *Store `di:"-"`
+
+	started bool `di:"-"`
+}
+
+func (s *Service) PostInject(ctx context.Context) error {
+	s.started = true
+	return nil
+}
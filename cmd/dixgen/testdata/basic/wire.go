@@ -0,0 +1,21 @@
+package basic
+
+import (
+	"context"
+
+	"github.com/jbterrylin/dix"
+)
+
+const MainKey dix.ValueKey = "main"
+
+func setup() error {
+	if _, err := dix.LoadConfig[*Config](dix.Env("APP_")); err != nil {
+		return err
+	}
+	if err := dix.Add(MainKey, &Store{Name: "main"}, dix.WithValueSetDefault()); err != nil {
+		return err
+	}
+	return dix.AddCtxProvider("cache", func(ctx context.Context) (*Store, error) {
+		return &Store{Name: "cache"}, nil
+	})
+}
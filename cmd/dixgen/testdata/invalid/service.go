@@ -0,0 +1,22 @@
+package invalid
+
+import (
+	"github.com/jbterrylin/dix"
+)
+
+type Store struct{}
+
+type Cache struct{}
+
+type Service struct {
+	Store    *Store `di:"key:main"`
+	Cache    *Cache `di:"key:cache"`
+	Provider *Store `di:"type:provider;key:main"`
+	Typo     *Store `di:"optinal"`
+	Reload   *Store `di:"key:main;reload"`
+	store    *Store
+}
+
+func setup() {
+	dix.Add[*Store]("cache", &Store{})
+}
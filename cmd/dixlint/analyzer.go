@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+
+	"github.com/jbterrylin/dix/cmd/internal/registry"
+	"github.com/jbterrylin/dix/internal/ditag"
+)
+
+// Analyzer reports misuses of dix. See the package doc.
+var Analyzer = &analysis.Analyzer{
+	Name:      "dixlint",
+	Doc:       "report misuses of dix: invalid di tags, unregistered keys, unknown inject func variables and MustGet outside main/init",
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(bindingsFact)},
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	insp.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		checkStruct(pass, n.(*ast.StructType))
+	})
+
+	bindings := exportBindings(pass)
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		name, typeArgs := registry.Func(pass.TypesInfo, call)
+		switch name {
+		case "GetByKey", "MustGetByKey":
+			checkGetByKey(pass, bindings, call, name, typeArgs)
+		case "InjectFunc":
+			checkInjectFuncVariables(pass, call, 0)
+		case "InjectFuncWithCtx", "Invoke":
+			checkInjectFuncVariables(pass, call, 1)
+		}
+	})
+
+	for _, file := range pass.Files {
+		checkMustGet(pass, file)
+	}
+	return nil, nil
+}
+
+// checkStruct reports invalid di tags, and di tags on unexported fields.
+func checkStruct(pass *analysis.Pass, st *ast.StructType) {
+	for _, field := range st.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+		raw, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			continue
+		}
+		tag, ok := reflect.StructTag(raw).Lookup(ditag.Name)
+		if !ok || tag == ditag.Skip {
+			continue
+		}
+
+		if token, reason := ditag.Validate(tag); reason != "" {
+			pass.Reportf(field.Tag.Pos(), "invalid di tag %q: %v", token, reason)
+		}
+		for _, name := range fieldNames(field) {
+			if !ast.IsExported(name) {
+				pass.Reportf(field.Pos(), "di tag on unexported field %v: InjectStruct fails with ErrFieldCannotBeSet", name)
+			}
+		}
+	}
+}
+
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) == 0 {
+		// embedded field
+		typ := field.Type
+		if star, ok := typ.(*ast.StarExpr); ok {
+			typ = star.X
+		}
+		switch t := typ.(type) {
+		case *ast.Ident:
+			return []string{t.Name}
+		case *ast.SelectorExpr:
+			return []string{t.Sel.Name}
+		}
+		return nil
+	}
+
+	names := make([]string, 0, len(field.Names))
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	return names
+}
+
+// checkGetByKey reports a constant key never registered for the type argument, if the type is registered
+// in the package or a package it imports. Otherwise a package importing it, e.g. main, may register it.
+func checkGetByKey(pass *analysis.Pass, bindings []factBinding, call *ast.CallExpr, name string, typeArgs []types.Type) {
+	if len(typeArgs) != 1 || len(call.Args) != 1 {
+		return
+	}
+	key, ok := registry.ConstantString(pass.TypesInfo, call.Args[0])
+	if !ok {
+		return
+	}
+
+	typ := types.TypeString(typeArgs[0], nil)
+	registered := false
+	var mismatch *factBinding
+	for i, b := range bindings {
+		if b.Kind != registry.Value {
+			continue
+		}
+		if b.Type == typ {
+			registered = true
+			if b.matches(key) {
+				return
+			}
+			continue
+		}
+		if mismatch == nil && !b.Dynamic && b.matches(key) {
+			mismatch = &bindings[i]
+		}
+	}
+	if !registered {
+		return
+	}
+
+	qualifier := func(p *types.Package) string { return p.Name() }
+	if mismatch != nil {
+		pass.Reportf(call.Pos(), "%v: value key %q is registered for %v, not %v", name, key,
+			mismatch.Name, types.TypeString(typeArgs[0], qualifier))
+		return
+	}
+	pass.Reportf(call.Pos(), "%v: no value registered for %v with key %q", name, types.TypeString(typeArgs[0], qualifier), key)
+}
+
+// checkInjectFuncVariables reports WithInjectFunc* options whose variable matches no parameter of the func at fnIndex.
+func checkInjectFuncVariables(pass *analysis.Pass, call *ast.CallExpr, fnIndex int) {
+	if len(call.Args) <= fnIndex {
+		return
+	}
+	sig, ok := pass.TypesInfo.TypeOf(call.Args[fnIndex]).Underlying().(*types.Signature)
+	if !ok {
+		return
+	}
+
+	for _, arg := range call.Args[fnIndex+1:] {
+		opt, ok := arg.(*ast.CallExpr)
+		if !ok || len(opt.Args) == 0 {
+			continue
+		}
+		name, _ := registry.Func(pass.TypesInfo, opt)
+		if !strings.HasPrefix(name, "WithInjectFunc") {
+			continue
+		}
+		variable, ok := registry.ConstantString(pass.TypesInfo, opt.Args[0])
+		if !ok {
+			continue
+		}
+		if matched, known := matchVariable(sig, variable); known && !matched {
+			pass.Reportf(opt.Args[0].Pos(), "%v: variable %q matches no parameter of %v", name, variable,
+				types.TypeString(sig, func(p *types.Package) string { return p.Name() }))
+		}
+	}
+}
+
+// matchVariable reports whether variable is a param index or a param type name of sig, like InjectFunc does.
+// known is false if a param type name cannot be told without reflection.
+func matchVariable(sig *types.Signature, variable string) (matched bool, known bool) {
+	if i, err := strconv.Atoi(variable); err == nil {
+		return i >= 0 && i < sig.Params().Len(), true
+	}
+
+	known = true
+	for i := 0; i < sig.Params().Len(); i++ {
+		name, ok := reflectName(sig.Params().At(i).Type())
+		if !ok {
+			known = false
+			continue
+		}
+		if name == variable {
+			return true, true
+		}
+	}
+	return false, known
+}
+
+// reflectName returns the reflect.Type.Name of t.
+func reflectName(t types.Type) (string, bool) {
+	switch t := types.Unalias(t).(type) {
+	case *types.Named:
+		if t.TypeArgs().Len() > 0 {
+			// includes the type arguments with their package paths
+			return "", false
+		}
+		return t.Obj().Name(), true
+	case *types.Basic:
+		// byte and rune are named uint8 and int32
+		return types.Typ[t.Kind()].Name(), true
+	}
+	return "", true
+}
+
+// checkMustGet reports MustGet* calls outside main and init. Test files are skipped, since a panic fails the test.
+func checkMustGet(pass *analysis.Pass, file *ast.File) {
+	if strings.HasSuffix(pass.Fset.File(file.Pos()).Name(), "_test.go") {
+		return
+	}
+
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil &&
+			(fn.Name.Name == "init" || (fn.Name.Name == "main" && pass.Pkg.Name() == "main")) {
+			continue
+		}
+		ast.Inspect(decl, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if name, _ := registry.Func(pass.TypesInfo, call); strings.HasPrefix(name, "MustGet") {
+				pass.Reportf(call.Pos(), "%v called outside main and init: it panics on error, return the error of %v instead",
+					name, strings.TrimPrefix(name, "Must"))
+			}
+			return true
+		})
+	}
+}
+
+// bindingsFact is the registrations of a package, exported so that the packages importing it see them
+// without loading it again.
+type bindingsFact struct {
+	Bindings []factBinding
+}
+
+func (*bindingsFact) AFact() {}
+
+func (f *bindingsFact) String() string {
+	keys := make([]string, 0, len(f.Bindings))
+	for _, b := range f.Bindings {
+		keys = append(keys, fmt.Sprintf("%v %v %q", b.Kind, b.Name, b.Key))
+	}
+	return "bindings(" + strings.Join(keys, ", ") + ")"
+}
+
+// factBinding is a registry.Binding with its type as a string, as facts cannot hold a types.Type.
+type factBinding struct {
+	Kind       registry.Kind
+	Type       string // with the package path, to compare
+	Name       string // with the package name, to report
+	Key        string
+	Dynamic    bool
+	SetDefault bool
+}
+
+// matches reports whether the binding is registered under key, like registry.Registry.Lookup.
+func (b factBinding) matches(key string) bool {
+	return b.Key == key || b.Dynamic || (key == "" && b.SetDefault)
+}
+
+// exportBindings exports the registrations of pass as a fact, and returns them with those of every package it imports.
+func exportBindings(pass *analysis.Pass) []factBinding {
+	var bindings []factBinding
+	// dix registers its own generic values, e.g. in LoadConfig
+	if pass.Pkg.Path() != registry.DixPath {
+		r := &registry.Registry{}
+		r.AddFiles(pass.Fset, pass.Files, pass.TypesInfo)
+		qualifier := func(p *types.Package) string { return p.Name() }
+		for _, b := range r.Bindings {
+			bindings = append(bindings, factBinding{
+				Kind:       b.Kind,
+				Type:       types.TypeString(b.Type, nil),
+				Name:       types.TypeString(b.Type, qualifier),
+				Key:        b.Key,
+				Dynamic:    b.Dynamic,
+				SetDefault: b.SetDefault,
+			})
+		}
+	}
+	if len(bindings) > 0 {
+		pass.ExportPackageFact(&bindingsFact{Bindings: bindings})
+	}
+
+	// facts of the packages imported, directly or not
+	for _, fact := range pass.AllPackageFacts() {
+		if tmp, ok := fact.Fact.(*bindingsFact); ok && fact.Package != pass.Pkg {
+			bindings = append(bindings, tmp.Bindings...)
+		}
+	}
+	return bindings
+}
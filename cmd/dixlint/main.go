@@ -0,0 +1,24 @@
+// Command dixlint reports misuses of dix that only fail at runtime:
+//
+//   - `di` tags with unknown or contradictory options;
+//   - `di` tags on unexported fields, which InjectStruct fails with ErrFieldCannotBeSet;
+//   - GetByKey / MustGetByKey calls whose constant key is never registered for their type;
+//   - WithInjectFunc* variables matching no parameter of the injected func, which fail with ErrInvalidVariable;
+//   - MustGet* calls outside main and init, outside tests.
+//
+// Registrations are read from the dix calls (Add, AddProvider, AddCtxProvider, AddStruct, Provide,
+// LoadConfig) of the analyzed package, and of the packages it imports through analysis facts.
+// A type registered by none of them is not checked, as a package importing it, e.g. main, may register it.
+//
+// Usage:
+//
+//	go vet -vettool=$(which dixlint) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(Analyzer)
+}
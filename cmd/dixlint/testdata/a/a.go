@@ -0,0 +1,71 @@
+package a // want package:`bindings\(value \*a.Store "main", value \*a.Cache "cache"\)`
+
+import (
+	"context"
+
+	"github.com/jbterrylin/dix"
+)
+
+type Store struct{}
+
+type Cache struct{}
+
+// Late is registered by main only.
+type Late struct{}
+
+type Service struct {
+	Store  *Store `di:"key:main"`
+	Typo   *Store `di:"optinal"`         // want `invalid di tag "optinal": unknown option`
+	Reload *Store `di:"key:main;reload"` // want `invalid di tag "reload": reload is only for providers`
+	store  *Store `di:"key:main"`        // want `di tag on unexported field store: InjectStruct fails with ErrFieldCannotBeSet`
+	cache  *Cache
+	Skip   *Store `di:"-"`
+}
+
+const MainKey dix.ValueKey = "main"
+
+func Register() error {
+	if err := dix.Add(MainKey, &Store{}); err != nil {
+		return err
+	}
+	return dix.Add("cache", &Cache{})
+}
+
+func Lookup() error {
+	if _, err := dix.GetByKey[*Store](MainKey); err != nil {
+		return err
+	}
+	if _, err := dix.GetByKey[*Store]("missing"); err != nil { // want `GetByKey: no value registered for \*a.Store with key "missing"`
+		return err
+	}
+	if _, err := dix.GetByKey[*Cache]("main"); err != nil { // want `GetByKey: value key "main" is registered for \*a.Store, not \*a.Cache`
+		return err
+	}
+	if _, err := dix.GetByKey[*Late]("late"); err != nil {
+		return err
+	}
+	key := dix.ValueKey("dynamic")
+	_, err := dix.GetByKey[*Store](key)
+	return err
+}
+
+func Inject(ctx context.Context) error {
+	fn := func(ctx context.Context, s *Store, n int, c Cache) {}
+	return dix.InjectFuncWithCtx(ctx, fn,
+		dix.WithInjectFuncKey("1", "main"),
+		dix.WithInjectFuncKey("int", "main"),
+		dix.WithInjectFuncKey("Cache", "main"),
+		dix.WithInjectFuncKey("4", "main"),  // want `WithInjectFuncKey: variable "4" matches no parameter of func\(ctx context.Context, s \*a.Store, n int, c a.Cache\)`
+		dix.WithInjectFuncOptional("Store"), // want `WithInjectFuncOptional: variable "Store" matches no parameter`
+	)
+}
+
+func Must() *Store {
+	return dix.MustGetByKey[*Store](MainKey) // want `MustGetByKey called outside main and init: it panics on error, return the error of GetByKey instead`
+}
+
+var global = dix.MustGet[*Store]() // want `MustGet called outside main and init`
+
+func init() {
+	_ = dix.MustGet[*Store]()
+}
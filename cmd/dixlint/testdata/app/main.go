@@ -0,0 +1,21 @@
+package main // want package:`bindings\(value \*a.Late "late"\)`
+
+import (
+	"github.com/jbterrylin/dix"
+
+	"example.com/lint/a"
+)
+
+func main() {
+	_ = a.Register()
+	dix.Add("late", &a.Late{})
+	_ = dix.MustGetByKey[*a.Store](a.MainKey)
+	_ = dix.MustGetByKey[*a.Store]("missing") // want `MustGetByKey: no value registered for \*a.Store with key "missing"`
+	go func() {
+		_ = dix.MustGet[*a.Store]()
+	}()
+}
+
+func run() {
+	_ = dix.MustGetProvider[*a.Store]() // want `MustGetProvider called outside main and init`
+}
@@ -0,0 +1,7 @@
+module example.com/lint
+
+go 1.20
+
+require github.com/jbterrylin/dix v0.0.0
+
+replace github.com/jbterrylin/dix => ../../..
@@ -0,0 +1,254 @@
+// Package registry collects the dix registration calls of type-checked packages.
+package registry
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/jbterrylin/dix/internal/ditag"
+)
+
+// DixPath is the import path of dix.
+const DixPath = "github.com/jbterrylin/dix"
+
+// configKey is dix.ConfigValueKey.
+const configKey = "config"
+
+// Kind is the kind of a binding.
+type Kind int
+
+const (
+	Value Kind = iota
+	Provider
+)
+
+func (k Kind) String() string {
+	if k == Provider {
+		return "provider"
+	}
+	return "value"
+}
+
+// Binding is a registration found in the scanned packages.
+type Binding struct {
+	Kind Kind
+	Type types.Type
+	Key  string
+	// the key is not a constant, so it may be any key
+	Dynamic bool
+	// registered under the default key too
+	SetDefault bool
+	Pos        token.Position
+}
+
+// Registry is the bindings of the scanned packages.
+type Registry struct {
+	Bindings []Binding
+}
+
+// Scan collects every dix registration call of pkgs.
+func Scan(pkgs []*packages.Package) *Registry {
+	r := &Registry{}
+	for _, pkg := range pkgs {
+		r.AddFiles(pkg.Fset, pkg.Syntax, pkg.TypesInfo)
+	}
+	return r
+}
+
+// AddFiles collects every dix registration call of files.
+func (r *Registry) AddFiles(fset *token.FileSet, files []*ast.File, info *types.Info) {
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if ok {
+				r.scanCall(fset, info, call)
+			}
+			return true
+		})
+	}
+}
+
+// Func returns the name of the dix function called by call, and its type arguments.
+func Func(info *types.Info, call *ast.CallExpr) (string, []types.Type) {
+	fun := call.Fun
+	switch f := fun.(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+
+	var ident *ast.Ident
+	switch f := fun.(type) {
+	case *ast.SelectorExpr:
+		ident = f.Sel
+	case *ast.Ident:
+		ident = f
+	default:
+		return "", nil
+	}
+
+	obj, ok := info.Uses[ident].(*types.Func)
+	if !ok || obj.Pkg() == nil || obj.Pkg().Path() != DixPath {
+		return "", nil
+	}
+
+	var typeArgs []types.Type
+	if inst, ok := info.Instances[ident]; ok {
+		for i := 0; i < inst.TypeArgs.Len(); i++ {
+			typeArgs = append(typeArgs, inst.TypeArgs.At(i))
+		}
+	}
+	return obj.Name(), typeArgs
+}
+
+func (r *Registry) scanCall(fset *token.FileSet, info *types.Info, call *ast.CallExpr) {
+	name, typeArgs := Func(info, call)
+	pos := fset.Position(call.Pos())
+
+	switch name {
+	case "Add":
+		if len(typeArgs) == 1 && len(call.Args) >= 2 {
+			r.add(Value, typeArgs[0], info, call.Args[0], hasOption(info, call.Args[2:], "WithValueSetDefault"), pos)
+		}
+	case "AddProvider", "AddCtxProvider", "AddStruct":
+		if len(typeArgs) == 1 && len(call.Args) >= 1 {
+			optIndex := 2
+			if name == "AddStruct" {
+				optIndex = 1
+			}
+			r.add(Provider, typeArgs[0], info, call.Args[0], hasOption(info, call.Args[optIndex:], "WithProviderSetDefault"), pos)
+		}
+	case "Provide":
+		if len(call.Args) >= 2 {
+			r.addConstructor(info, call, pos)
+		}
+	case "LoadConfig", "LoadConfigWithOptions":
+		if len(typeArgs) == 1 {
+			setDefault := name == "LoadConfigWithOptions" && len(call.Args) >= 1 && hasOption(info, call.Args[1:], "WithConfigSetDefault")
+			r.Bindings = append(r.Bindings, Binding{Kind: Value, Type: typeArgs[0], Key: configKey, SetDefault: setDefault, Pos: pos})
+		}
+	}
+}
+
+func (r *Registry) add(kind Kind, typ types.Type, info *types.Info, keyExpr ast.Expr, setDefault bool, pos token.Position) {
+	key, ok := ConstantString(info, keyExpr)
+	r.Bindings = append(r.Bindings, Binding{Kind: kind, Type: typ, Key: key, Dynamic: !ok, SetDefault: setDefault, Pos: pos})
+}
+
+// addConstructor adds the provider of the first result of a dix.Provide constructor, or of each field of a dix.Out result.
+func (r *Registry) addConstructor(info *types.Info, call *ast.CallExpr, pos token.Position) {
+	sig, ok := info.TypeOf(call.Args[1]).Underlying().(*types.Signature)
+	if !ok || sig.Results().Len() == 0 {
+		return
+	}
+	key, isConst := ConstantString(info, call.Args[0])
+	setDefault := hasOption(info, call.Args[2:], "WithProviderSetDefault")
+
+	rt := sig.Results().At(0).Type()
+	st, ok := rt.Underlying().(*types.Struct)
+	if !ok || !hasEmbedded(st, "Out") {
+		r.Bindings = append(r.Bindings, Binding{Kind: Provider, Type: rt, Key: key, Dynamic: !isConst, SetDefault: setDefault, Pos: pos})
+		return
+	}
+
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		tag := reflect.StructTag(st.Tag(i)).Get(ditag.Name)
+		if !field.Exported() || IsDixNamed(field.Type(), "Out") || tag == ditag.Skip {
+			continue
+		}
+
+		fieldKey, fieldConst := key, isConst
+		if tmp := ditag.Parse(tag).Key; tmp != "" {
+			fieldKey, fieldConst = tmp, true
+		}
+		r.Bindings = append(r.Bindings, Binding{Kind: Provider, Type: field.Type(), Key: fieldKey, Dynamic: !fieldConst, SetDefault: setDefault, Pos: pos})
+	}
+}
+
+// Lookup returns the bindings of kind registered under key, with any type.
+func (r *Registry) Lookup(kind Kind, key string) []Binding {
+	var found []Binding
+	for _, b := range r.Bindings {
+		if b.Kind == kind && (b.Key == key || b.Dynamic || (key == "" && b.SetDefault)) {
+			found = append(found, b)
+		}
+	}
+	return found
+}
+
+// HasConfig reports whether a config is loaded with dix.LoadConfig.
+func (r *Registry) HasConfig() bool {
+	for _, b := range r.Bindings {
+		if b.Kind == Value && !b.Dynamic && b.Key == configKey {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve reports whether a binding of kind and typ exists under one of keys.
+// If not, mismatch is a binding under one of keys with another type, if any.
+func (r *Registry) Resolve(kind Kind, typ types.Type, keys []string) (found bool, mismatch *Binding) {
+	for _, key := range keys {
+		for _, b := range r.Lookup(kind, key) {
+			if sameType(b.Type, typ) {
+				return true, nil
+			}
+			if mismatch == nil && !b.Dynamic {
+				tmp := b
+				mismatch = &tmp
+			}
+		}
+	}
+	return false, mismatch
+}
+
+// sameType reports whether a and b are the same type, even if they are from different loads.
+func sameType(a, b types.Type) bool {
+	return types.Identical(a, b) || types.TypeString(a, nil) == types.TypeString(b, nil)
+}
+
+// ConstantString returns the value of expr if it is a string constant.
+func ConstantString(info *types.Info, expr ast.Expr) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+// hasOption reports whether args has a call to the dix option name.
+func hasOption(info *types.Info, args []ast.Expr, name string) bool {
+	for _, arg := range args {
+		call, ok := arg.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		if fn, _ := Func(info, call); fn == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasEmbedded(st *types.Struct, name string) bool {
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i).Embedded() && IsDixNamed(st.Field(i).Type(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDixNamed reports whether t is the dix type name.
+func IsDixNamed(t types.Type, name string) bool {
+	named, ok := t.(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == DixPath && named.Obj().Name() == name
+}
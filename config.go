@@ -0,0 +1,597 @@
+package dix
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const configStructTag = "config"
+const configDefaultStructTag = "default"
+const configRequiredOpt = "required"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// ConfigSource loads a config tree.
+// Keys of the tree are matched case-insensitively against the `config` tag or the field name.
+type ConfigSource interface {
+	Load() (map[string]any, error)
+}
+
+type ConfigSourceFunc func() (map[string]any, error)
+
+func (f ConfigSourceFunc) Load() (map[string]any, error) {
+	return f()
+}
+
+type configEntry struct {
+	mu     sync.Mutex
+	seq    uint64 // registration order, to resolve config leaves deterministically
+	reload func() error
+}
+
+// JSONFile reads a JSON object from path.
+func JSONFile(path string) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]any, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return decodeJSONConfig(data)
+	})
+}
+
+// JSONBytes reads a JSON object from data.
+func JSONBytes(data []byte) ConfigSource {
+	return ConfigSourceFunc(func() (map[string]any, error) {
+		return decodeJSONConfig(data)
+	})
+}
+
+// Env reads environment variables starting with prefix, followed by "_" unless prefix is empty or ends with it.
+// The rest of the name is lowercased and split by "_" into a path, and "__" stands for a "_" within a name,
+// e.g. with prefix APP, APP_DATABASE_DSN -> database.dsn and APP_DATABASE_MAX__CONNS -> database.max_conns.
+func Env(prefix string) ConfigSource {
+	prefix = envPrefix(prefix)
+	return ConfigSourceFunc(func() (map[string]any, error) {
+		tree := make(map[string]any)
+		for _, kv := range os.Environ() {
+			key, value, _ := strings.Cut(kv, "=")
+			setEnvConfig(tree, prefix, key, value)
+		}
+		return tree, nil
+	})
+}
+
+// EnvFile reads KEY=VALUE lines of a .env file, with the same key rules as Env.
+// Empty lines and lines starting with # are ignored.
+func EnvFile(path string, prefix string) ConfigSource {
+	prefix = envPrefix(prefix)
+	return ConfigSourceFunc(func() (map[string]any, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		tree := make(map[string]any)
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			line = strings.TrimPrefix(line, "export ")
+
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("failed at env file=%v, line=%q: %w", path, line, ErrInvalidConfig)
+			}
+			value = strings.TrimSpace(value)
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				value = unquoted
+			} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+				value = value[1 : len(value)-1]
+			}
+			setEnvConfig(tree, prefix, strings.TrimSpace(key), value)
+		}
+		return tree, scanner.Err()
+	})
+}
+
+func decodeJSONConfig(data []byte) (map[string]any, error) {
+	tree := make(map[string]any)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// envPrefix appends the "_" separating prefix from the path, so APP and APP_ are the same prefix.
+func envPrefix(prefix string) string {
+	if prefix == "" || strings.HasSuffix(prefix, "_") {
+		return prefix
+	}
+	return prefix + "_"
+}
+
+func setEnvConfig(tree map[string]any, prefix string, key string, value string) {
+	if !strings.HasPrefix(key, prefix) || len(key) == len(prefix) {
+		return
+	}
+
+	// "__" is a "_" within a name, kept as NUL while splitting
+	rest := strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(key, prefix)), "__", "\x00")
+	path := strings.Split(rest, "_")
+	for i, part := range path {
+		if part == "" {
+			// e.g. a trailing "_", which names nothing
+			return
+		}
+		path[i] = strings.ReplaceAll(part, "\x00", "_")
+	}
+	node := tree
+	for _, part := range path[:len(path)-1] {
+		child, ok := node[part].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			node[part] = child
+		}
+		node = child
+	}
+	node[path[len(path)-1]] = value
+}
+
+// LoadConfig merges sources in order (later ones win), decodes the result into T and registers it
+// under ConfigValueKey. See LoadConfigWithOptions to register it as the default value of T too.
+//
+// Fields are matched by the `config:"name"` tag or the field name, and `config:"name,required"` fails when missing.
+// Missing fields fall back to the `default:"..."` tag. If T (or *T) has a Validate() error method, it runs last.
+func LoadConfig[T any](sources ...ConfigSource) (T, error) {
+	return LoadConfigWithOptions[T](sources)
+}
+
+// LoadConfigWithOptions is LoadConfig with options, e.g. WithConfigSetDefault.
+func LoadConfigWithOptions[T any](sources []ConfigSource, opts ...ConfigOption) (T, error) {
+	// handle options
+	var opt configOption
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	var addOpts []ValueAddOption
+	if opt.setDefault {
+		addOpts = append(addOpts, WithValueSetDefault())
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	cfg, err := loadConfig[T](sources)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	if err := Add(ConfigValueKey, cfg, addOpts...); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	Container.configMap.Set(t, &configEntry{
+		seq: Container.nextSeq(),
+		reload: func() error {
+			newCfg, err := loadConfig[T](sources)
+			if err != nil {
+				return err
+			}
+
+			oldValue, _ := getContainerNestedMapValue(Container.typeKeyValueMap, t, ConfigValueKey)
+			// replaces every key at once
+			if err := Add(ConfigValueKey, newCfg, addOpts...); err != nil {
+				return err
+			}
+
+			var old any
+			if oldValue != nil {
+				old = oldValue.value
+			}
+			Container.afterConfigReload.run(func(f AfterConfigReloadFunc) { f(NewAfterConfigReloadCtx(t, ConfigValueKey, old, newCfg)) })
+			return nil
+		},
+	})
+
+	return cfg, nil
+}
+
+// ReloadConfig loads the sources given to LoadConfig again and replaces the registered T.
+// The old config stays registered if loading fails.
+func ReloadConfig[T any]() error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	entry, exist := Container.configMap.Get(t)
+	if !exist {
+		return ErrValueNotFound
+	}
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	return entry.reload()
+}
+
+func loadConfig[T any](sources []ConfigSource) (T, error) {
+	var cfg T
+
+	tree := make(map[string]any)
+	for _, source := range sources {
+		tmp, err := source.Load()
+		if err != nil {
+			return cfg, err
+		}
+		mergeConfigTree(tree, tmp)
+	}
+
+	v := reflect.ValueOf(&cfg).Elem()
+	if err := decodeConfigValue(tree, v, ""); err != nil {
+		return cfg, err
+	}
+
+	if err := validateConfig(v); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func validateConfig(v reflect.Value) error {
+	type validator interface {
+		Validate() error
+	}
+
+	if val, ok := v.Interface().(validator); ok {
+		return val.Validate()
+	}
+	if v.CanAddr() {
+		if val, ok := v.Addr().Interface().(validator); ok {
+			return val.Validate()
+		}
+	}
+	return nil
+}
+
+// mergeConfigTree merges src into dst, the later value winning. Keys keep their case, since maps decode them as is,
+// but a key of src matches a key of dst case-insensitively, so sources can name struct fields differently.
+func mergeConfigTree(dst, src map[string]any) {
+	for key, value := range src {
+		if existing, ok := configTreeKey(dst, key); ok {
+			key = existing
+		}
+		srcChild, srcIsMap := value.(map[string]any)
+		dstChild, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeConfigTree(dstChild, srcChild)
+			continue
+		}
+		if srcIsMap {
+			value = copyConfigTree(srcChild)
+		}
+		dst[key] = value
+	}
+}
+
+// copyConfigTree returns a deep copy of the maps of tree, so merging into it does not modify a source.
+func copyConfigTree(tree map[string]any) map[string]any {
+	tmp := make(map[string]any, len(tree))
+	for key, value := range tree {
+		if child, ok := value.(map[string]any); ok {
+			value = copyConfigTree(child)
+		}
+		tmp[key] = value
+	}
+	return tmp
+}
+
+// configTreeKey returns the key of tree equal to key, or else equal to it case-insensitively.
+func configTreeKey(tree map[string]any, key string) (string, bool) {
+	if _, ok := tree[key]; ok {
+		return key, true
+	}
+	for existing := range tree {
+		if strings.EqualFold(existing, key) {
+			return existing, true
+		}
+	}
+	return "", false
+}
+
+// configFieldName returns the name of field in the config tree, and whether it's required.
+func configFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get(configStructTag)
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return strings.ToLower(name), opts == configRequiredOpt
+}
+
+func decodeConfigValue(node any, v reflect.Value, path string) error {
+	if node == nil {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeConfigValue(node, v.Elem(), path)
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf(node))
+			return nil
+		}
+	case reflect.Struct:
+		if !isLiteralType(v.Type()) {
+			tree, ok := node.(map[string]any)
+			if !ok {
+				return fmt.Errorf("failed at config path=%v: %w", path, ErrTypeMismatch)
+			}
+			return decodeConfigStruct(tree, v, path)
+		}
+	case reflect.Map:
+		tree, ok := node.(map[string]any)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("failed at config path=%v: %w", path, ErrTypeMismatch)
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(tree)))
+		}
+		for key, child := range tree {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeConfigValue(child, elem, joinFieldPath(path, key)); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		return nil
+	case reflect.Slice:
+		var items []any
+		switch tmp := node.(type) {
+		case []any:
+			items = tmp
+		case string:
+			// comma separated list, e.g. from environment variables
+			for _, item := range strings.Split(tmp, ",") {
+				items = append(items, strings.TrimSpace(item))
+			}
+		default:
+			return fmt.Errorf("failed at config path=%v: %w", path, ErrTypeMismatch)
+		}
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeConfigValue(item, slice.Index(i), fmt.Sprintf("%v[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	// scalar
+	var literal string
+	switch tmp := node.(type) {
+	case string:
+		literal = tmp
+	case json.Number, bool:
+		literal = fmt.Sprint(tmp)
+	default:
+		return fmt.Errorf("failed at config path=%v: %w", path, ErrTypeMismatch)
+	}
+	if err := decodeLiteral(literal, v); err != nil {
+		return fmt.Errorf("failed at config path=%v: %w", path, err)
+	}
+	return nil
+}
+
+func decodeConfigStruct(tree map[string]any, v reflect.Value, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get(configStructTag) == "-" {
+			continue
+		}
+
+		name, required := configFieldName(field)
+		fieldPath := joinFieldPath(path, name)
+		fieldVal := v.Field(i)
+
+		var node any
+		key, exist := configTreeKey(tree, name)
+		if exist {
+			node = tree[key]
+		}
+		switch {
+		case exist:
+			if err := decodeConfigValue(node, fieldVal, fieldPath); err != nil {
+				return err
+			}
+		case required:
+			return fmt.Errorf("failed at config path=%v: %w", fieldPath, ErrConfigRequired)
+		case field.Tag.Get(configDefaultStructTag) != "":
+			if err := decodeLiteral(field.Tag.Get(configDefaultStructTag), fieldVal); err != nil {
+				return fmt.Errorf("failed at config path=%v: %w", fieldPath, err)
+			}
+		case fieldVal.Kind() == reflect.Struct && !isLiteralType(fieldVal.Type()):
+			// nested defaults
+			if err := decodeConfigStruct(map[string]any{}, fieldVal, fieldPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// isLiteralType reports whether t is decoded from a single literal, like time.Time.
+func isLiteralType(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// decodeLiteral decodes s into v for strings, numbers, bools, time.Duration and encoding.TextUnmarshaler.
+func decodeLiteral(s string, v reflect.Value) error {
+	if v.CanAddr() {
+		if unmarshaler, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return unmarshaler.UnmarshalText([]byte(s))
+		}
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeLiteral(s, v.Elem())
+	default:
+		return ErrTypeMismatch
+	}
+	return nil
+}
+
+// getFromConfig resolves a single leaf, e.g. "database.dsn", from the configs registered by LoadConfig.
+// If several configs have the leaf, the one loaded first wins.
+func getFromConfig(typ reflect.Type, path string) (*reflect.Value, error) {
+	type config struct {
+		t   reflect.Type
+		seq uint64
+	}
+	var configs []config
+	Container.configMap.Range(func(t reflect.Type, entry *configEntry) bool {
+		configs = append(configs, config{t: t, seq: entry.seq})
+		return true
+	})
+	sort.Slice(configs, func(i, j int) bool {
+		return configs[i].seq < configs[j].seq
+	})
+
+	var (
+		leaf  reflect.Value
+		found bool
+	)
+	for _, c := range configs {
+		cfg, err := getContainerNestedMapValue(Container.typeKeyValueMap, c.t, ConfigValueKey)
+		if err != nil {
+			continue
+		}
+		if leaf, found = lookupConfigPath(reflect.ValueOf(cfg.value), strings.Split(strings.ToLower(path), ".")); found {
+			break
+		}
+	}
+	if !found {
+		return nil, ErrValueNotFound
+	}
+
+	switch {
+	case leaf.Type().AssignableTo(typ):
+		return &leaf, nil
+	case leaf.Kind() == reflect.String && typ.Kind() != reflect.String:
+		tmp := reflect.New(typ).Elem()
+		if err := decodeLiteral(leaf.String(), tmp); err != nil {
+			return nil, err
+		}
+		return &tmp, nil
+	case leaf.Type().ConvertibleTo(typ) && (leaf.Kind() == typ.Kind() || isNumberKind(leaf.Kind()) && isNumberKind(typ.Kind())):
+		tmp := leaf.Convert(typ)
+		return &tmp, nil
+	default:
+		return nil, ErrTypeMismatch
+	}
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func lookupConfigPath(v reflect.Value, path []string) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	if len(path) == 0 {
+		return v, true
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if name, _ := configFieldName(field); name == path[0] {
+				return lookupConfigPath(v.Field(i), path[1:])
+			}
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			if strings.ToLower(iter.Key().String()) == path[0] {
+				return lookupConfigPath(iter.Value(), path[1:])
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
@@ -0,0 +1,227 @@
+package dix
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// constructorRunner runs a Provide constructor, shared by every provider registered from it.
+type constructorRunner struct {
+	mu         sync.Mutex
+	fn         any
+	hasCleanup bool
+	noCache    bool
+	isRun      bool
+	value      reflect.Value
+	cleanup    func(context.Context) error // of the last run
+
+	// number of providers which are not closed yet
+	refs int
+}
+
+// run returns the value of the last run, or runs the constructor if there is none, reload is set or it is not cached.
+// The cleanup of the replaced run, if any, runs once the new run succeeds.
+func (r *constructorRunner) run(ctx context.Context, reload bool) (reflect.Value, error) {
+	r.mu.Lock()
+	if r.isRun && !r.noCache && !reload {
+		defer r.mu.Unlock()
+		return r.value, nil
+	}
+
+	out, err := callInjectFunc(ctx, r.fn)
+	if err != nil {
+		r.mu.Unlock()
+		return reflect.Value{}, err
+	}
+
+	replaced := r.cleanup
+	r.cleanup = nil
+	if len(out) > 1 && !out[1].IsNil() {
+		switch cleanup := out[1].Interface().(type) {
+		case func():
+			r.cleanup = func(context.Context) error {
+				cleanup()
+				return nil
+			}
+		case func(context.Context) error:
+			r.cleanup = cleanup
+		}
+	}
+	r.isRun = true
+	r.value = out[0]
+	value := r.value
+	r.mu.Unlock()
+
+	if replaced != nil {
+		// the new value is already returned to the caller, so the error has nowhere to go
+		_ = replaced(ctx)
+	}
+	return value, nil
+}
+
+// release runs the cleanup once the last provider is closed.
+func (r *constructorRunner) release(ctx context.Context) error {
+	r.mu.Lock()
+	r.refs--
+	if r.refs > 0 {
+		r.mu.Unlock()
+		return nil
+	}
+	cleanup := r.cleanup
+	r.cleanup = nil
+	r.isRun = false
+	r.value = reflect.Value{}
+	r.mu.Unlock()
+
+	if cleanup == nil {
+		return nil
+	}
+	return cleanup(ctx)
+}
+
+// Provide registers constructor as the provider of its first result type.
+// Its params are injected like InjectFuncWithCtx with the ctx of the provider, including a dix.In param struct.
+//
+// constructor may return (R), (R, error), (R, cleanup) or (R, cleanup, error),
+// where cleanup is func() or func(context.Context) error and runs when the provider is deleted or reset,
+// or once a reload replaced the value. A constructor with a cleanup cannot be used with WithProviderNoCache.
+// If R is a dix.Out result struct, each of its fields is registered as its own provider instead,
+// either all of them or none if a BeforeDuplicateRegister hook fails. It cannot be used with WithProviderNoCache.
+func Provide(key ProviderKey, constructor any, opts ...ProviderAddOption) error {
+	if constructor == nil {
+		return ErrValueIsNil
+	}
+	if !key.IsValid() {
+		return ErrInvalidKey
+	}
+
+	ct := reflect.TypeOf(constructor)
+	if ct.Kind() != reflect.Func {
+		return ErrInjectFuncMustBeFunc
+	}
+	if !isConstructorType(ct) {
+		return fmt.Errorf("failed at constructor type=%v: %w", ct, ErrTypeMismatch)
+	}
+
+	// handle options
+	var opt providerAddOption
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	// a value which is never cached has no run to clean up after
+	if opt.noCache && hasConstructorCleanup(ct) {
+		return fmt.Errorf("failed at constructor type=%v, option=WithProviderNoCache: %w", ct, ErrInvalidOption)
+	}
+
+	runner := &constructorRunner{
+		fn:         constructor,
+		hasCleanup: hasConstructorCleanup(ct),
+		noCache:    opt.noCache,
+	}
+
+	rt := ct.Out(0)
+	if !isOutStruct(rt) {
+		runner.refs = 1
+		return addContainerProvider(rt, key, newConstructorProvider(runner, nil, opt), opt)
+	}
+
+	// every field provider shares one constructor run, and its cleanups
+	if opt.noCache {
+		return fmt.Errorf("failed at constructor type=%v, option=WithProviderNoCache: %w", ct, ErrInvalidOption)
+	}
+
+	type outProvider struct {
+		t        reflect.Type
+		key      ProviderKey
+		keys     []ProviderKey
+		provider *containerProvider
+	}
+	var providers []outProvider
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		tag := field.Tag.Get(injectStructTag)
+		if !field.IsExported() || field.Type == outType || tag == "-" {
+			continue
+		}
+
+		fieldKey := key
+		if tmp := parseDITag(tag).key; tmp != "" {
+			fieldKey = ProviderKey(tmp)
+		}
+
+		// nothing is set until every field passed its hooks, so there is nothing to roll back
+		tmp := newConstructorProvider(runner, field.Index, opt)
+		keys, err := checkContainerProvider(field.Type, fieldKey, tmp, opt)
+		if err != nil {
+			return fmt.Errorf("failed at field name=%v, field type=%v: %w", field.Name, field.Type, err)
+		}
+		providers = append(providers, outProvider{t: field.Type, key: fieldKey, keys: keys, provider: tmp})
+	}
+
+	runner.refs = len(providers)
+	for _, p := range providers {
+		setContainerProvider(p.t, p.key, p.keys, p.provider)
+	}
+	return nil
+}
+
+// newConstructorProvider returns a provider of the value returned by runner, or its field at index.
+func newConstructorProvider(runner *constructorRunner, index []int, opt providerAddOption) *containerProvider {
+	valueWithReload := func(ctx context.Context, reload bool) (any, error) {
+		val, err := runner.run(ctx, reload)
+		if err != nil {
+			return nil, err
+		}
+		if index != nil {
+			val = val.FieldByIndex(index)
+		}
+		return val.Interface(), nil
+	}
+	tmp := newCtxContainerProvider(
+		func(ctx context.Context) (any, error) {
+			return valueWithReload(ctx, false)
+		},
+		opt.noCache,
+		opt.priority,
+		opt.tagMap,
+	)
+	tmp.valueWithReload = valueWithReload
+	if runner.hasCleanup {
+		tmp.onCloseHook = runner.release
+	}
+	return tmp
+}
+
+var cleanupType = reflect.TypeOf(func() {})
+var ctxCleanupType = reflect.TypeOf(func(context.Context) error { return nil })
+
+// constructorNumOut returns the number of results of ct, excluding the trailing error.
+func constructorNumOut(ct reflect.Type) int {
+	numOut := ct.NumOut()
+	if numOut > 0 && ct.Out(numOut-1) == errorType {
+		numOut--
+	}
+	return numOut
+}
+
+func isConstructorType(ct reflect.Type) bool {
+	switch constructorNumOut(ct) {
+	case 1:
+		return true
+	case 2:
+		return hasConstructorCleanup(ct)
+	default:
+		return false
+	}
+}
+
+func hasConstructorCleanup(ct reflect.Type) bool {
+	if constructorNumOut(ct) != 2 {
+		return false
+	}
+	cleanup := ct.Out(1)
+	return cleanup == cleanupType || cleanup == ctxCleanupType
+}
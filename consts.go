@@ -9,10 +9,29 @@ var ErrInvalidKey = errors.New("invalid key")
 var ErrValueNotFound = errors.New("value not found")
 var ErrInjectStructMustBePointerStruct = errors.New("inject struct must be pointer struct")
 var ErrFieldCannotBeSet = errors.New("field cannot be set")
+var ErrFieldNotFound = errors.New("field not found")
 var ErrInjectFuncMustBeFunc = errors.New("inject func must be func")
 var ErrInvalidVariable = errors.New("invalid variable")
 var ErrTypeMismatch = errors.New("type mismatch")
 var ErrRefCounterBelowZero = errors.New("ref counter below zero")
+var ErrRefCountTimeout = errors.New("ref count wait timeout")
+var ErrOnCloseHookPanic = errors.New("on close hook panic")
+var ErrInvalidConfig = errors.New("invalid config")
+var ErrConfigRequired = errors.New("config required")
+var ErrInvalidTag = errors.New("invalid tag")
+var ErrInlineCycle = errors.New("inline struct cycle")
+var ErrHookPanic = errors.New("hook panic")
+var ErrInvalidOption = errors.New("invalid option")
 
 var DefaultValueKey ValueKey = ""
 var DefaultProviderKey ProviderKey = ""
+
+// SecretTag marks a value or provider whose value Describe never renders, unless the tag is set to false,
+// e.g. WithValueTag(map[string]any{dix.SecretTag: true}).
+const SecretTag = "secret"
+
+// RedactedValue replaces redacted values in a Description.
+const RedactedValue = "[REDACTED]"
+
+// ConfigValueKey is the key LoadConfig registers configs under.
+var ConfigValueKey ValueKey = "config"
@@ -9,13 +9,24 @@ import (
 var _ iContainerData = &containerProvider{}
 
 type containerProvider struct {
+	// runMu serializes runs of the factory function, and is held by lock with mu.
+	// Hooks of a run are called holding runMu only, so they can read the provider.
+	runMu          sync.Mutex
 	mu             sync.RWMutex
 	value          func() (any, error)
 	valueWithCtx   func(context.Context) (any, error)
 	isValueWithCtx bool
-	noCache        bool
-	cacheValue     any
-	isAccessed     bool
+	// set for Provide constructors, which cache their value themselves and need to know about reloads
+	valueWithReload func(context.Context, bool) (any, error)
+	noCache         bool
+	cacheValue      any
+	isAccessed      bool
+	onCloseHook     func(context.Context) error
+	isClosed        bool
+	isPostInjected  bool // the factory function runs PostInject itself
+
+	seq      uint64
+	priority int
 
 	createdAt  time.Time
 	accessedAt time.Time
@@ -25,11 +36,14 @@ type containerProvider struct {
 func newContainerProvider(
 	value func() (any, error),
 	noCache bool,
+	priority int,
 	tagMap map[string]any,
 ) *containerProvider {
 	return &containerProvider{
 		value:     value,
 		noCache:   noCache,
+		seq:       Container.nextSeq(),
+		priority:  priority,
 		createdAt: time.Now(),
 		tagMap:    tagMap,
 	}
@@ -38,12 +52,15 @@ func newContainerProvider(
 func newCtxContainerProvider(
 	valueWithCtx func(context.Context) (any, error),
 	noCache bool,
+	priority int,
 	tagMap map[string]any,
 ) *containerProvider {
 	return &containerProvider{
 		valueWithCtx:   valueWithCtx,
 		isValueWithCtx: true,
 		noCache:        noCache,
+		seq:            Container.nextSeq(),
+		priority:       priority,
 		createdAt:      time.Now(),
 		tagMap:         tagMap,
 	}
@@ -54,15 +71,41 @@ func (c *containerProvider) setAccessed() {
 	c.accessedAt = time.Now()
 }
 
+// lock waits for a running factory function too.
 func (c *containerProvider) lock() {
+	c.runMu.Lock()
 	c.mu.Lock()
 }
 
 func (c *containerProvider) unlock() {
 	c.mu.Unlock()
+	c.runMu.Unlock()
+}
+
+// closer returns the close of the provider, at most once since the default key shares the provider with its key.
+func (c *containerProvider) closer() func(ctx context.Context, forceClose bool) (refErr error, closeErr error) {
+	if !c.needClose() {
+		return nil
+	}
+	c.isClosed = true
+	cacheValue, onCloseHook := c.cacheValue, c.onCloseHook
+	return func(ctx context.Context, _ bool) (error, error) {
+		return nil, runCloseHooks(ctx, cacheValue, onCloseHook)
+	}
+}
+
+// needClose reports whether the provider has an OnCloseHook or a cached value with a PreClose method, not run yet.
+func (c *containerProvider) needClose() bool {
+	_, ok := c.cacheValue.(PreCloser)
+	return (c.onCloseHook != nil || ok) && !c.isClosed
+}
+
+func (c *containerProvider) order() (priority int, seq uint64) {
+	return c.priority, c.seq
 }
 
-func (c *containerProvider) triggerOnCloseHook() {
+func (c *containerProvider) refCount() int64 {
+	return 0
 }
 
 // func (c *containerProvider) GetValue() func() (any, error) { return c.value }
@@ -74,6 +117,7 @@ func (c *containerProvider) GetIsValueWithCtx() bool   { return c.isValueWithCtx
 func (c *containerProvider) GetNoCache() bool          { return c.noCache }
 func (c *containerProvider) GetCacheValue() any        { return c.cacheValue }
 func (c *containerProvider) GetIsAccessed() bool       { return c.isAccessed }
+func (c *containerProvider) GetPriority() int          { return c.priority }
 func (c *containerProvider) GetCreatedAt() time.Time   { return c.createdAt }
 func (c *containerProvider) GetAccessedAt() time.Time  { return c.accessedAt }
 func (c *containerProvider) GetTagMap() map[string]any { return copyMap(c.tagMap) }
@@ -1,6 +1,7 @@
 package dix
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -11,12 +12,15 @@ var _ iContainerData = &containerValue{}
 type containerValue struct {
 	mu          sync.RWMutex
 	value       any
-	onCloseHook func()
+	onCloseHook func(context.Context) error
 	isAccessed  bool
 
 	refCounter     int64
 	refCounterCond *sync.Cond
 
+	seq      uint64
+	priority int
+
 	createdAt  time.Time
 	accessedAt time.Time
 	tagMap     map[string]any
@@ -24,7 +28,8 @@ type containerValue struct {
 
 func newContainerValue(
 	value any,
-	onCloseHook func(),
+	onCloseHook func(context.Context) error,
+	priority int,
 	tagMap map[string]any,
 ) *containerValue {
 	return &containerValue{
@@ -33,6 +38,9 @@ func newContainerValue(
 
 		refCounterCond: sync.NewCond(&sync.Mutex{}),
 
+		seq:      Container.nextSeq(),
+		priority: priority,
+
 		createdAt: time.Now(),
 		tagMap:    tagMap,
 	}
@@ -51,13 +59,32 @@ func (c *containerValue) unlock() {
 	c.mu.Unlock()
 }
 
-func (c *containerValue) triggerOnCloseHook() {
-	if c.onCloseHook != nil {
-		c.waitUntilRefZero()
-		c.onCloseHook()
+// closer returns the close of the value, which waits until the ref counter reaches zero before running the hook.
+// If ctx ends first, the hook only runs when forceClose is set, and refErr is ctx's error either way.
+// A forced hook runs with context.Background() since ctx is already done.
+// closeErr is the error returned by the hook itself, and by PreClose if the value implements PreCloser.
+func (c *containerValue) closer() func(ctx context.Context, forceClose bool) (refErr error, closeErr error) {
+	if !c.needClose() {
+		return nil
+	}
+	return func(ctx context.Context, forceClose bool) (refErr error, closeErr error) {
+		refErr = c.waitUntilRefZero(ctx)
+		if refErr != nil {
+			if !forceClose {
+				return refErr, nil
+			}
+			ctx = context.Background()
+		}
+		return refErr, runCloseHooks(ctx, c.value, c.onCloseHook)
 	}
 }
 
+// needClose reports whether the value has an OnCloseHook or a PreClose method to run.
+func (c *containerValue) needClose() bool {
+	_, ok := c.value.(PreCloser)
+	return c.onCloseHook != nil || ok
+}
+
 func (c *containerValue) refCounterIncr() {
 	if !Container.safeDelete {
 		return
@@ -80,21 +107,53 @@ func (c *containerValue) refCounterDecr() {
 	}
 }
 
-func (c *containerValue) waitUntilRefZero() {
+func (c *containerValue) order() (priority int, seq uint64) {
+	return c.priority, c.seq
+}
+
+func (c *containerValue) refCount() int64 {
+	return atomic.LoadInt64(&c.refCounter)
+}
+
+func (c *containerValue) waitUntilRefZero(ctx context.Context) error {
 	if !Container.safeDelete {
-		return
+		return nil
 	}
-	c.refCounterCond.L.Lock()
-	for atomic.LoadInt64(&c.refCounter) > 0 {
-		c.refCounterCond.Wait()
+
+	var stopped int32
+	done := make(chan struct{})
+	go func() {
+		c.refCounterCond.L.Lock()
+		for atomic.LoadInt64(&c.refCounter) > 0 && atomic.LoadInt32(&stopped) == 0 {
+			c.refCounterCond.Wait()
+		}
+		c.refCounterCond.L.Unlock()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		// wake the waiter up so it won't leak
+		atomic.StoreInt32(&stopped, 1)
+		c.refCounterCond.L.Lock()
+		c.refCounterCond.Broadcast()
+		c.refCounterCond.L.Unlock()
+		<-done
+
+		if atomic.LoadInt64(&c.refCounter) == 0 {
+			return nil
+		}
+		return ctx.Err()
 	}
-	c.refCounterCond.L.Unlock()
 }
 
 // func (c *containerValue) GetValue() any             { return c.value }
-// func (c *containerValue) GetOnCloseHook() func()    { return c.onCloseHook }
+// func (c *containerValue) GetOnCloseHook() func(context.Context) error { return c.onCloseHook }
 func (c *containerValue) GetIsAccessed() bool       { return c.isAccessed }
-func (c *containerValue) GetRefCounter() int64      { return c.refCounter }
+func (c *containerValue) GetRefCounter() int64      { return c.refCount() }
+func (c *containerValue) GetPriority() int          { return c.priority }
 func (c *containerValue) GetCreatedAt() time.Time   { return c.createdAt }
 func (c *containerValue) GetAccessedAt() time.Time  { return c.accessedAt }
 func (c *containerValue) GetTagMap() map[string]any { return copyMap(c.tagMap) }
@@ -102,8 +161,9 @@ func (c *containerValue) GetTagMap() map[string]any { return copyMap(c.tagMap) }
 func (c *containerValue) OnCloseHookExist() bool {
 	return c.onCloseHook != nil
 }
-func (c *containerValue) TriggerOnCloseHook() {
+func (c *containerValue) TriggerOnCloseHook() error {
 	if c.onCloseHook != nil {
-		c.onCloseHook()
+		return runOnCloseHook(context.Background(), c.onCloseHook)
 	}
+	return nil
 }
@@ -0,0 +1,380 @@
+package dix
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jbterrylin/dix/internal/mapx"
+)
+
+// Description is a snapshot of every registered value and provider, see Describe.
+type Description struct {
+	Types []TypeDescription `json:"types"`
+}
+
+// TypeDescription is the values, providers and contributions of a type, each sorted like ListKeys,
+// ListProviderKeys and GetGroup.
+type TypeDescription struct {
+	Type          string                    `json:"type"` // with the package path, e.g. *github.com/acme/app/db.DB
+	Values        []ValueDescription        `json:"values,omitempty"`
+	Providers     []ProviderDescription     `json:"providers,omitempty"`
+	Contributions []ContributionDescription `json:"contributions,omitempty"`
+}
+
+type ValueDescription struct {
+	Key          ValueKey       `json:"key"`
+	IsDefault    bool           `json:"isDefault"` // registered under DefaultValueKey, as Key or as an alias of Key
+	Priority     int            `json:"priority"`
+	CreatedAt    time.Time      `json:"createdAt"`
+	AccessedAt   time.Time      `json:"accessedAt"`
+	IsAccessed   bool           `json:"isAccessed"`
+	RefCount     int64          `json:"refCount"`
+	Tags         map[string]any `json:"tags,omitempty"`
+	HasCloseHook bool           `json:"hasCloseHook"`
+	Value        string         `json:"value"` // RedactedValue if redacted
+	Redacted     bool           `json:"redacted"`
+}
+
+type ProviderDescription struct {
+	Key            ProviderKey    `json:"key"`
+	IsDefault      bool           `json:"isDefault"` // registered under DefaultProviderKey, as Key or as an alias of Key
+	Priority       int            `json:"priority"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	AccessedAt     time.Time      `json:"accessedAt"`
+	IsAccessed     bool           `json:"isAccessed"`
+	Tags           map[string]any `json:"tags,omitempty"`
+	NoCache        bool           `json:"noCache"`
+	HasCachedValue bool           `json:"hasCachedValue"`
+	HasCloseHook   bool           `json:"hasCloseHook"`
+	CachedValue    string         `json:"cachedValue,omitempty"` // RedactedValue if redacted
+	Redacted       bool           `json:"redacted"`
+}
+
+// ContributionDescription is a value or provider added to a group by Contribute, ContributeProvider
+// or ContributeCtxProvider.
+type ContributionDescription struct {
+	Name           string         `json:"name,omitempty"`
+	IsProvider     bool           `json:"isProvider"`
+	Priority       int            `json:"priority"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	AccessedAt     time.Time      `json:"accessedAt"`
+	IsAccessed     bool           `json:"isAccessed"`
+	Tags           map[string]any `json:"tags,omitempty"`
+	NoCache        bool           `json:"noCache"`
+	HasCachedValue bool           `json:"hasCachedValue"` // always true for values
+	HasCloseHook   bool           `json:"hasCloseHook"`
+	Value          string         `json:"value,omitempty"` // the value or the cached value, RedactedValue if redacted
+	Redacted       bool           `json:"redacted"`
+}
+
+// Describe returns a snapshot of everything registered, with types sorted by name.
+// Like InspectValue, it does not mark anything accessed, trigger hooks other than Redact, or run providers.
+// Values are rendered with %+v, unless tagged with SecretTag or redacted by a Redact subscriber.
+func Describe() Description {
+	// collect types first, since describing one reads the maps again
+	types := make(map[reflect.Type]*TypeDescription)
+	Container.typeKeyValueMap.Range(func(t reflect.Type, _ *mapx.SafeMap[ValueKey, *containerValue]) bool {
+		types[t] = &TypeDescription{Type: typeName(t)}
+		return true
+	})
+	Container.typeKeyProviderMap.Range(func(t reflect.Type, _ *mapx.SafeMap[ProviderKey, *containerProvider]) bool {
+		types[t] = &TypeDescription{Type: typeName(t)}
+		return true
+	})
+	Container.typeGroupMap.Range(func(t reflect.Type, _ *group) bool {
+		types[t] = &TypeDescription{Type: typeName(t)}
+		return true
+	})
+
+	d := Description{Types: make([]TypeDescription, 0, len(types))}
+	for t, tmp := range types {
+		tmp.Values = describeValues(t)
+		tmp.Providers = describeProviders(t)
+		tmp.Contributions = describeContributions(t)
+		// types emptied by deletes
+		if len(tmp.Values) > 0 || len(tmp.Providers) > 0 || len(tmp.Contributions) > 0 {
+			d.Types = append(d.Types, *tmp)
+		}
+	}
+	// names are unique, stable keeps the output the same anyway
+	sort.SliceStable(d.Types, func(i, j int) bool {
+		return d.Types[i].Type < d.Types[j].Type
+	})
+	return d
+}
+
+// typeName is t.String() with package paths instead of package names, so types of packages
+// with the same name are told apart.
+func typeName(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			// predeclared, e.g. string or error
+			return t.Name()
+		}
+		return t.PkgPath() + "." + t.Name()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + typeName(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), typeName(t.Elem()))
+	case reflect.Map:
+		return "map[" + typeName(t.Key()) + "]" + typeName(t.Elem())
+	case reflect.Chan:
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			return "<-chan " + typeName(t.Elem())
+		case reflect.SendDir:
+			return "chan<- " + typeName(t.Elem())
+		}
+		return "chan " + typeName(t.Elem())
+	}
+	// func, struct and interface literals
+	return t.String()
+}
+
+func describeValues(t reflect.Type) []ValueDescription {
+	keys, vals := listSorted(Container.typeKeyValueMap, t, DefaultValueKey)
+	defaultValue, _ := getContainerNestedMapValue(Container.typeKeyValueMap, t, DefaultValueKey)
+
+	// a default value not shared with another key comes first
+	if defaultValue != nil {
+		aliased := false
+		for _, val := range vals {
+			aliased = aliased || val == defaultValue
+		}
+		if !aliased {
+			keys = append([]ValueKey{DefaultValueKey}, keys...)
+			vals = append([]*containerValue{defaultValue}, vals...)
+		}
+	}
+
+	values := make([]ValueDescription, 0, len(vals))
+	for i, val := range vals {
+		val.mu.RLock()
+		info := val.info(t, keys[i])
+		value := val.value
+		val.mu.RUnlock()
+
+		key := keys[i]
+		rendered, redacted := renderValue(NewRedactCtx(t, &key, nil, info.Tags, value))
+		values = append(values, ValueDescription{
+			Key:          info.Key,
+			IsDefault:    val == defaultValue,
+			Priority:     info.Priority,
+			CreatedAt:    info.CreatedAt,
+			AccessedAt:   info.AccessedAt,
+			IsAccessed:   info.IsAccessed,
+			RefCount:     info.RefCount,
+			Tags:         info.Tags,
+			HasCloseHook: info.HasCloseHook,
+			Value:        rendered,
+			Redacted:     redacted,
+		})
+	}
+	return values
+}
+
+func describeProviders(t reflect.Type) []ProviderDescription {
+	keys, vals := listSorted(Container.typeKeyProviderMap, t, DefaultProviderKey)
+	defaultProvider, _ := getContainerNestedMapValue(Container.typeKeyProviderMap, t, DefaultProviderKey)
+
+	// a default provider not shared with another key comes first
+	if defaultProvider != nil {
+		aliased := false
+		for _, provider := range vals {
+			aliased = aliased || provider == defaultProvider
+		}
+		if !aliased {
+			keys = append([]ProviderKey{DefaultProviderKey}, keys...)
+			vals = append([]*containerProvider{defaultProvider}, vals...)
+		}
+	}
+
+	providers := make([]ProviderDescription, 0, len(vals))
+	for i, provider := range vals {
+		provider.mu.RLock()
+		info := provider.info(t, keys[i])
+		cacheValue := provider.cacheValue
+		provider.mu.RUnlock()
+
+		var rendered string
+		var redacted bool
+		if info.HasCachedValue {
+			key := keys[i]
+			rendered, redacted = renderValue(NewRedactCtx(t, nil, &key, info.Tags, cacheValue))
+		}
+		providers = append(providers, ProviderDescription{
+			Key:            info.Key,
+			IsDefault:      provider == defaultProvider,
+			Priority:       info.Priority,
+			CreatedAt:      info.CreatedAt,
+			AccessedAt:     info.AccessedAt,
+			IsAccessed:     info.IsAccessed,
+			Tags:           info.Tags,
+			NoCache:        info.NoCache,
+			HasCachedValue: info.HasCachedValue,
+			HasCloseHook:   info.HasCloseHook,
+			CachedValue:    rendered,
+			Redacted:       redacted,
+		})
+	}
+	return providers
+}
+
+func describeContributions(t reflect.Type) []ContributionDescription {
+	g, exist := Container.typeGroupMap.Get(t)
+	if !exist {
+		return nil
+	}
+
+	entries := g.snapshot()
+	contributions := make([]ContributionDescription, 0, len(entries))
+	for _, entry := range entries {
+		if entry.value != nil {
+			entry.value.mu.RLock()
+			info := entry.value.info(t, ValueKey(entry.name))
+			value := entry.value.value
+			entry.value.mu.RUnlock()
+
+			rendered, redacted := renderValue(NewRedactCtx(t, nil, nil, info.Tags, value))
+			contributions = append(contributions, ContributionDescription{
+				Name:           entry.name,
+				Priority:       info.Priority,
+				CreatedAt:      info.CreatedAt,
+				AccessedAt:     info.AccessedAt,
+				IsAccessed:     info.IsAccessed,
+				Tags:           info.Tags,
+				HasCachedValue: true,
+				HasCloseHook:   info.HasCloseHook,
+				Value:          rendered,
+				Redacted:       redacted,
+			})
+			continue
+		}
+
+		entry.provider.mu.RLock()
+		info := entry.provider.info(t, ProviderKey(entry.name))
+		cacheValue := entry.provider.cacheValue
+		entry.provider.mu.RUnlock()
+
+		var rendered string
+		var redacted bool
+		if info.HasCachedValue {
+			rendered, redacted = renderValue(NewRedactCtx(t, nil, nil, info.Tags, cacheValue))
+		}
+		contributions = append(contributions, ContributionDescription{
+			Name:           entry.name,
+			IsProvider:     true,
+			Priority:       info.Priority,
+			CreatedAt:      info.CreatedAt,
+			AccessedAt:     info.AccessedAt,
+			IsAccessed:     info.IsAccessed,
+			Tags:           info.Tags,
+			NoCache:        info.NoCache,
+			HasCachedValue: info.HasCachedValue,
+			HasCloseHook:   info.HasCloseHook,
+			Value:          rendered,
+			Redacted:       redacted,
+		})
+	}
+	return contributions
+}
+
+// renderValue returns the value of ctx rendered with %+v, or RedactedValue if it is secret.
+func renderValue(ctx RedactCtx) (string, bool) {
+	if secret, exist := ctx.Tags[SecretTag]; exist && secret != false {
+		return RedactedValue, true
+	}
+	for _, entry := range Container.redact.list() {
+		// a panicking subscriber redacts, so a secret is never printed by mistake
+		redacted := true
+		_ = Container.redact.safeCall(func() error {
+			redacted = entry.f(ctx)
+			return nil
+		})
+		if redacted {
+			return RedactedValue, true
+		}
+	}
+	return fmt.Sprintf("%+v", ctx.Value), false
+}
+
+// JSON renders d as indented JSON. It fails if a tag value cannot be marshaled.
+func (d Description) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// String renders d as text, one line per value, provider and contribution under its type, followed by its value if any.
+func (d Description) String() string {
+	var sb strings.Builder
+	for _, typ := range d.Types {
+		sb.WriteString(typ.Type + "\n")
+		for _, val := range typ.Values {
+			fmt.Fprintf(&sb, "  value %q%s priority=%d refCount=%d created=%s accessed=%s closeHook=%t%s\n",
+				val.Key, describeDefault(val.IsDefault), val.Priority, val.RefCount,
+				describeTime(val.CreatedAt, true), describeTime(val.AccessedAt, val.IsAccessed), val.HasCloseHook, describeTags(val.Tags))
+			fmt.Fprintf(&sb, "    = %s\n", val.Value)
+		}
+		for _, provider := range typ.Providers {
+			fmt.Fprintf(&sb, "  provider %q%s priority=%d noCache=%t cached=%t created=%s accessed=%s closeHook=%t%s\n",
+				provider.Key, describeDefault(provider.IsDefault), provider.Priority, provider.NoCache, provider.HasCachedValue,
+				describeTime(provider.CreatedAt, true), describeTime(provider.AccessedAt, provider.IsAccessed), provider.HasCloseHook, describeTags(provider.Tags))
+			if provider.HasCachedValue {
+				fmt.Fprintf(&sb, "    = %s\n", provider.CachedValue)
+			}
+		}
+		for _, c := range typ.Contributions {
+			kind := "value"
+			if c.IsProvider {
+				kind = "provider"
+			}
+			fmt.Fprintf(&sb, "  contribution %s %q priority=%d noCache=%t cached=%t created=%s accessed=%s closeHook=%t%s\n",
+				kind, c.Name, c.Priority, c.NoCache, c.HasCachedValue,
+				describeTime(c.CreatedAt, true), describeTime(c.AccessedAt, c.IsAccessed), c.HasCloseHook, describeTags(c.Tags))
+			if c.HasCachedValue {
+				fmt.Fprintf(&sb, "    = %s\n", c.Value)
+			}
+		}
+	}
+	return sb.String()
+}
+
+func describeDefault(isDefault bool) string {
+	if isDefault {
+		return " (default)"
+	}
+	return ""
+}
+
+func describeTime(t time.Time, ok bool) string {
+	if !ok {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// describeTags renders tags sorted by name, e.g. " tags=[env=prod secret=true]".
+func describeTags(tags map[string]any) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%v", name, tags[name]))
+	}
+	return " tags=[" + strings.Join(parts, " ") + "]"
+}
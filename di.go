@@ -1,9 +1,12 @@
 package dix
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/jbterrylin/dix/internal/mapx"
 )
@@ -28,17 +31,57 @@ func SetResetMaxConcurrent(resetMaxConcurrent int) {
 	Container.resetMaxConcurrent = resetMaxConcurrent
 }
 
+// SetInjectPlanCache enables caching the injection plan of each struct and func type. It is enabled by default.
+func SetInjectPlanCache(injectPlanCache bool) {
+	Container.injectPlanCache = injectPlanCache
+}
+
+// SetStrictTag makes InjectStruct return an *InvalidTagError for malformed or contradictory `di` tags
+// instead of ignoring them. It is disabled by default.
+func SetStrictTag(strictTag bool) {
+	Container.strictTag = strictTag
+}
+
+// SetHookPanicHandler sets the handler of panics recovered from hook subscribers, with the name of the hook.
+// Recovered panics are ignored by default.
+func SetHookPanicHandler(handler func(hook string, recovered any)) {
+	Container.hookPanicHandler = handler
+}
+
 var Container = newContainer()
 
 type (
 	container struct {
 		typeKeyValueMap    *mapx.SafeMap[reflect.Type, *mapx.SafeMap[ValueKey, *containerValue]]
 		typeKeyProviderMap *mapx.SafeMap[reflect.Type, *mapx.SafeMap[ProviderKey, *containerProvider]]
-
-		afterAdd                AfterAddFunc
-		afterProviderRun        AfterProviderRunFunc
-		afterFirstAccess        AfterFirstAccessFunc
-		beforeDuplicateRegister BeforeDuplicateRegisterFunc
+		typeGroupMap       *mapx.SafeMap[reflect.Type, *group]
+		configMap          *mapx.SafeMap[reflect.Type, *configEntry]
+
+		// compiled injection plans, never reset since they only depend on the type
+		structPlanMap   *mapx.SafeMap[reflect.Type, *structPlan]
+		funcPlanMap     *mapx.SafeMap[reflect.Type, *funcPlan]
+		injectPlanCache bool
+		strictTag       bool
+
+		// registration order
+		seq uint64
+
+		afterAdd                *hookList[AfterAddFunc]
+		afterProviderRun        *hookList[AfterProviderRunFunc]
+		afterFirstAccess        *hookList[AfterFirstAccessFunc]
+		beforeDuplicateRegister *hookList[BeforeDuplicateRegisterFunc]
+		afterConfigReload       *hookList[AfterConfigReloadFunc]
+		beforeProviderRun       *hookList[BeforeProviderRunFunc]
+		providerRunFailed       *hookList[ProviderRunFailedFunc]
+		providerCacheHit        *hookList[ProviderCacheHitFunc]
+		afterDelete             *hookList[AfterDeleteFunc]
+		beforeClose             *hookList[BeforeCloseFunc]
+		afterClose              *hookList[AfterCloseFunc]
+		beforeReset             *hookList[BeforeResetFunc]
+		afterReset              *hookList[AfterResetFunc]
+		afterReplace            *hookList[AfterReplaceFunc]
+		redact                  *hookList[RedactFunc]
+		hookPanicHandler        func(hook string, recovered any)
 
 		safeDelete bool
 
@@ -50,33 +93,89 @@ func newContainer() *container {
 	return &container{
 		typeKeyValueMap:    mapx.NewSafeMap[reflect.Type, *mapx.SafeMap[ValueKey, *containerValue]](),
 		typeKeyProviderMap: mapx.NewSafeMap[reflect.Type, *mapx.SafeMap[ProviderKey, *containerProvider]](),
+		typeGroupMap:       mapx.NewSafeMap[reflect.Type, *group](),
+		configMap:          mapx.NewSafeMap[reflect.Type, *configEntry](),
+		structPlanMap:      mapx.NewSafeMap[reflect.Type, *structPlan](),
+		funcPlanMap:        mapx.NewSafeMap[reflect.Type, *funcPlan](),
+		injectPlanCache:    true,
+
+		afterAdd:                newHookList[AfterAddFunc]("AfterAdd"),
+		afterProviderRun:        newHookList[AfterProviderRunFunc]("AfterProviderRun"),
+		afterFirstAccess:        newHookList[AfterFirstAccessFunc]("AfterFirstAccess"),
+		beforeDuplicateRegister: newHookList[BeforeDuplicateRegisterFunc]("BeforeDuplicateRegister"),
+		afterConfigReload:       newHookList[AfterConfigReloadFunc]("AfterConfigReload"),
+		beforeProviderRun:       newHookList[BeforeProviderRunFunc]("BeforeProviderRun"),
+		providerRunFailed:       newHookList[ProviderRunFailedFunc]("ProviderRunFailed"),
+		providerCacheHit:        newHookList[ProviderCacheHitFunc]("ProviderCacheHit"),
+		afterDelete:             newHookList[AfterDeleteFunc]("AfterDelete"),
+		beforeClose:             newHookList[BeforeCloseFunc]("BeforeClose"),
+		afterClose:              newHookList[AfterCloseFunc]("AfterClose"),
+		beforeReset:             newHookList[BeforeResetFunc]("BeforeReset"),
+		afterReset:              newHookList[AfterResetFunc]("AfterReset"),
+		afterReplace:            newHookList[AfterReplaceFunc]("AfterReplace"),
+		redact:                  newHookList[RedactFunc]("Redact"),
 
 		resetMaxConcurrent: 100,
 	}
 }
 
+func (c *container) nextSeq() uint64 {
+	return atomic.AddUint64(&c.seq, 1)
+}
+
 func Reset(opts ...ResetOption) []error {
+	return ResetWithCtx(context.Background(), opts...)
+}
+
+// When safe delete is enabled, waiting for the ref counters stops once ctx ends.
+// The OnCloseHook of each pending value is then skipped unless WithResetForceClose is set,
+// and a *RefCountTimeoutError listing every pending value is appended to the returned errors.
+func ResetWithCtx(ctx context.Context, opts ...ResetOption) []error {
 	// handle options
 	var opt resetOption
 	for _, o := range opts {
 		o(&opt)
 	}
 
-	errs := reset(opt.skipOnClose, Container.typeKeyValueMap, DefaultValueKey)
-	errs = append(errs, reset(opt.skipOnClose, Container.typeKeyProviderMap, DefaultProviderKey)...)
+	Container.beforeReset.run(func(f BeforeResetFunc) { f(NewBeforeResetCtx(opt.skipOnClose, opt.forceClose)) })
+	start := time.Now()
+
+	errs, leaks := reset(ctx, opt, Container.typeKeyValueMap, DefaultValueKey)
+	providerErrs, providerLeaks := reset(ctx, opt, Container.typeKeyProviderMap, DefaultProviderKey)
+	groupErrs, groupLeaks := resetGroups(ctx, opt)
+	errs = append(errs, providerErrs...)
+	errs = append(errs, groupErrs...)
+	leaks = append(leaks, providerLeaks...)
+	leaks = append(leaks, groupLeaks...)
 
+	if len(leaks) > 0 {
+		errs = append(errs, newRefCountTimeoutError(ctx.Err(), leaks...))
+	}
+
+	configTypes := make([]reflect.Type, 0, Container.configMap.Size())
+	Container.configMap.Range(func(typ reflect.Type, _ *configEntry) bool {
+		configTypes = append(configTypes, typ)
+		return true
+	})
+	for _, typ := range configTypes {
+		Container.configMap.Del(typ)
+	}
+
+	Container.afterReset.run(func(f AfterResetFunc) { f(NewAfterResetCtx(time.Since(start), errs)) })
 	return errs
 }
 
 func reset[Key ~string, Value iContainerData](
-	skipOnClose bool,
+	ctx context.Context,
+	opt resetOption,
 	typeKeyValueMap *mapx.SafeMap[reflect.Type, *mapx.SafeMap[Key, Value]],
 	defaultKey Key,
-) []error {
+) ([]error, []RefCountLeak) {
 	typeKeysMap := getTypeKeysMap(typeKeyValueMap)
 
 	var (
 		errs     = make([]error, 0)
+		leaks    = make([]RefCountLeak, 0)
 		errsLock sync.Mutex
 		wg       sync.WaitGroup
 	)
@@ -104,16 +203,24 @@ func reset[Key ~string, Value iContainerData](
 				// delete first so others won't see it
 				_, _ = deleteContainerNestedMapValue(typeKeyValueMap, typ, key)
 
-				val.lock()
-				defer val.unlock()
-
-				if !skipOnClose && key != defaultKey {
-					val.triggerOnCloseHook()
+				if opt.skipOnClose || key == defaultKey {
+					return
+				}
+				refErr, closeErr := closeContainerData(ctx, typ, key, val, opt.forceClose)
+				if refErr != nil {
+					errsLock.Lock()
+					leaks = append(leaks, RefCountLeak{Type: typ, Key: ValueKey(key), RefCount: val.refCount()})
+					errsLock.Unlock()
+				}
+				if closeErr != nil {
+					errsLock.Lock()
+					errs = append(errs, fmt.Errorf("failed at type=%v, key=%v: %w", typ, key, closeErr))
+					errsLock.Unlock()
 				}
 			}(typ, key)
 		}
 	}
 
 	wg.Wait()
-	return errs
+	return errs, leaks
 }
@@ -0,0 +1,82 @@
+package dix_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testStructService struct {
+	Store *Test          `di:"key:test"`
+	IVal  ITestInterface `di:"optional"`
+
+	postInjectCount int `di:"-"`
+}
+
+func (s *testStructService) PostInject(ctx context.Context) error {
+	s.postInjectCount++
+	return nil
+}
+
+func TestAddStruct(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"))
+
+	err := dix.AddStruct[*testStructService](TestProviderKey, dix.WithProviderSetDefault())
+	if err != nil {
+		t.Fatalf("unexpected AddStruct() err: got %v, want %v", err, nil)
+	}
+
+	svc, err := dix.GetProvider[*testStructService]()
+	if err != nil {
+		t.Fatalf("unexpected GetProvider() err: got %v, want %v", err, nil)
+	}
+	if svc.Store.Name() != "test" {
+		t.Errorf("unexpected Store.Name(): got %v, want %v", svc.Store.Name(), "test")
+	}
+	if svc.postInjectCount != 1 {
+		t.Errorf("unexpected postInjectCount: got %v, want %v", svc.postInjectCount, 1)
+	}
+
+	cached, _ := dix.GetProviderByKey[*testStructService](TestProviderKey)
+	if cached != svc {
+		t.Errorf("unexpected GetProviderByKey(): got %p, want %p", cached, svc)
+	}
+}
+
+func TestAddStructValue(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"))
+
+	err := dix.AddStruct[testStructService](TestProviderKey, dix.WithProviderNoCache())
+	if err != nil {
+		t.Fatalf("unexpected AddStruct() err: got %v, want %v", err, nil)
+	}
+
+	svc, err := dix.GetProviderByKey[testStructService](TestProviderKey)
+	if err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	if svc.Store.Name() != "test" {
+		t.Errorf("unexpected Store.Name(): got %v, want %v", svc.Store.Name(), "test")
+	}
+	if svc.postInjectCount != 1 {
+		t.Errorf("unexpected postInjectCount: got %v, want %v", svc.postInjectCount, 1)
+	}
+}
+
+func TestAddStructErr(t *testing.T) {
+	dix.Reset()
+
+	if err := dix.AddStruct[int](TestProviderKey); !errors.Is(err, dix.ErrInjectStructMustBePointerStruct) {
+		t.Errorf("unexpected AddStruct() err: got %v, want %v", err, dix.ErrInjectStructMustBePointerStruct)
+	}
+
+	dix.AddStruct[*testStructService](TestProviderKey)
+	_, err := dix.GetProviderByKey[*testStructService](TestProviderKey)
+	if !errors.Is(err, dix.ErrValueNotFound) {
+		t.Errorf("unexpected GetProviderByKey() err: got %v, want %v", err, dix.ErrValueNotFound)
+	}
+}
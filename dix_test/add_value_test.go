@@ -92,6 +92,8 @@ func TestAddBothAndGetCorrect(t *testing.T) {
 }
 
 func TestNoAdd(t *testing.T) {
+	dix.Reset()
+
 	_, err := dix.Get[*Test]()
 	if !errors.Is(err, dix.ErrValueNotFound) {
 		t.Errorf("unexpected Get() err: got %v, want %v", err, dix.ErrValueNotFound)
@@ -0,0 +1,159 @@
+package dix_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testAwait struct {
+	id int
+}
+
+type testAwaitTarget struct {
+	Await    *testAwait `di:"key:await"`
+	Optional *testAwait `di:"key:missing;optional"`
+}
+
+func TestAwait(t *testing.T) {
+	dix.Reset()
+
+	if err := dix.Add("exist", &testAwait{id: 1}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	v, err := dix.Await[*testAwait](context.Background(), "exist")
+	if err != nil || v.id != 1 {
+		t.Fatalf("unexpected Await() existing value: got %v, %v, want id %v", v, err, 1)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = dix.Add("other", &testAwait{id: 2})
+		_ = dix.Add("await", &testAwait{id: 3})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v, err = dix.Await[*testAwait](ctx, "await")
+	if err != nil || v.id != 3 {
+		t.Fatalf("unexpected Await() value: got %v, %v, want id %v", v, err, 3)
+	}
+}
+
+func TestAwaitTimeout(t *testing.T) {
+	dix.Reset()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	v, err := dix.Await[*testAwait](ctx, "await")
+	if v != nil {
+		t.Errorf("unexpected Await() value: got %v, want %v", v, nil)
+	}
+	if !errors.Is(err, dix.ErrValueNotFound) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected Await() err: got %v, want %v and %v", err, dix.ErrValueNotFound, context.DeadlineExceeded)
+	}
+}
+
+func TestAwaitProvider(t *testing.T) {
+	dix.Reset()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = dix.AddProvider("await", func() (*testAwait, error) {
+			return &testAwait{id: 1}, nil
+		})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	v, err := dix.AwaitProvider[*testAwait](ctx, "await")
+	if err != nil || v.id != 1 {
+		t.Fatalf("unexpected AwaitProvider() value: got %v, %v, want id %v", v, err, 1)
+	}
+
+	// an error of the factory function is returned instead of waiting
+	wantErr := errors.New("failed")
+	if err := dix.AddProvider("fail", func() (*testAwait, error) {
+		return nil, wantErr
+	}); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+	if _, err := dix.AwaitProvider[*testAwait](ctx, "fail"); !errors.Is(err, wantErr) {
+		t.Errorf("unexpected AwaitProvider() err: got %v, want %v", err, wantErr)
+	}
+}
+
+type testAwaitStruct struct {
+	Dep *testAwait `di:"key:dep"`
+}
+
+func TestAwaitProviderFactoryErr(t *testing.T) {
+	dix.Reset()
+
+	if err := dix.AddStruct[*testAwaitStruct]("struct"); err != nil {
+		t.Fatalf("unexpected AddStruct() err: got %v, want %v", err, nil)
+	}
+
+	// the provider is found, so a missing dependency of it is returned without waiting
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := dix.AwaitProvider[*testAwaitStruct](ctx, "struct")
+	if !errors.Is(err, dix.ErrValueNotFound) || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected AwaitProvider() err: got %v, want %v only", err, dix.ErrValueNotFound)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("unexpected AwaitProvider() wait: got %v, want no wait", elapsed)
+	}
+
+	shortCtx, shortCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer shortCancel()
+	var target struct {
+		Struct *testAwaitStruct `di:"type:provider;key:struct"`
+	}
+	err = dix.InjectStructWithCtx(shortCtx, &target, dix.AwaitMissing())
+	if !errors.Is(err, dix.ErrValueNotFound) || errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected InjectStructWithCtx() err: got %v, want %v only", err, dix.ErrValueNotFound)
+	}
+
+	if err := dix.Add("dep", &testAwait{id: 1}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	v, err := dix.AwaitProvider[*testAwaitStruct](ctx, "struct")
+	if err != nil || v.Dep == nil || v.Dep.id != 1 {
+		t.Errorf("unexpected AwaitProvider() value: got %v, %v, want dep id %v", v, err, 1)
+	}
+}
+
+func TestInjectStructAwaitMissing(t *testing.T) {
+	dix.Reset()
+
+	var target testAwaitTarget
+	if err := dix.InjectStruct(&target); !errors.Is(err, dix.ErrValueNotFound) {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, dix.ErrValueNotFound)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = dix.Add("await", &testAwait{id: 1})
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := dix.InjectStructWithCtx(ctx, &target, dix.AwaitMissing()); err != nil {
+		t.Fatalf("unexpected InjectStructWithCtx() err: got %v, want %v", err, nil)
+	}
+	if target.Await == nil || target.Await.id != 1 {
+		t.Errorf("unexpected InjectStructWithCtx() field: got %v, want id %v", target.Await, 1)
+	}
+	if target.Optional != nil {
+		t.Errorf("unexpected InjectStructWithCtx() optional field: got %v, want %v", target.Optional, nil)
+	}
+
+	dix.Reset()
+	timeoutCtx, timeoutCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer timeoutCancel()
+	if err := dix.InjectStructWithCtx(timeoutCtx, &testAwaitTarget{}, dix.AwaitMissing()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected InjectStructWithCtx() err: got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,123 @@
+package dix_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testCloser struct {
+	closed bool
+}
+
+func (c *testCloser) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestOnCloseHookErr(t *testing.T) {
+	errClose := errors.New("close failed")
+	err := dix.Add(TestKey, NewTest("test"), dix.WithValueOnCloseCtx(func(ctx context.Context) error {
+		return errClose
+	}))
+	if err != nil {
+		t.Errorf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	err = dix.DeleteByKey[*Test](TestKey)
+	if !errors.Is(err, errClose) {
+		t.Errorf("unexpected DeleteByKey() err: got %v, want %v", err, errClose)
+	}
+}
+
+func TestOnCloseHookPanic(t *testing.T) {
+	dix.Reset()
+
+	err := dix.Add(TestKey, NewTest("test"), dix.WithValueOnClose(func() {
+		panic("boom")
+	}))
+	if err != nil {
+		t.Errorf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	errs := dix.Reset()
+	if len(errs) != 1 || !errors.Is(errs[0], dix.ErrOnCloseHookPanic) {
+		t.Errorf("unexpected Reset() errs: got %v, want %v", errs, dix.ErrOnCloseHookPanic)
+	}
+}
+
+func TestOnCloseHookTimeout(t *testing.T) {
+	err := dix.Add(TestKey, NewTest("test"), dix.WithValueOnCloseCtx(func(ctx context.Context) error {
+		select {} // hang forever
+	}))
+	if err != nil {
+		t.Errorf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = dix.DeleteByKeyWithCtx[*Test](ctx, TestKey)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected DeleteByKeyWithCtx() err: got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestOnCloseHookCanceled(t *testing.T) {
+	dix.Reset()
+
+	called := make(chan struct{}, 1)
+	err := dix.Add(TestKey, NewTest("test"), dix.WithValueOnCloseCtx(func(ctx context.Context) error {
+		called <- struct{}{}
+		return nil
+	}))
+	if err != nil {
+		t.Errorf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = dix.DeleteByKeyWithCtx[*Test](ctx, TestKey)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected DeleteByKeyWithCtx() err: got %v, want %v", err, context.Canceled)
+	}
+	select {
+	case <-called:
+		t.Errorf("unexpected OnCloseHook call with a canceled ctx")
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+func TestAutoClose(t *testing.T) {
+	closer := &testCloser{}
+	err := dix.Add(TestKey, closer)
+	if err != nil {
+		t.Errorf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	err = dix.DeleteByKey[*testCloser](TestKey)
+	if err != nil {
+		t.Errorf("unexpected DeleteByKey() err: got %v, want %v", err, nil)
+	}
+	if !closer.closed {
+		t.Errorf("unexpected closed: got %v, want %v", closer.closed, true)
+	}
+
+	closer = &testCloser{}
+	err = dix.Add(TestKey, closer, dix.WithValueSkipAutoClose())
+	if err != nil {
+		t.Errorf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	err = dix.DeleteByKey[*testCloser](TestKey)
+	if err != nil {
+		t.Errorf("unexpected DeleteByKey() err: got %v, want %v", err, nil)
+	}
+	if closer.closed {
+		t.Errorf("unexpected closed: got %v, want %v", closer.closed, false)
+	}
+}
@@ -0,0 +1,223 @@
+package dix_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jbterrylin/dix"
+)
+
+type TestConfig struct {
+	Name     string
+	Database struct {
+		DSN      string        `config:"dsn,required"`
+		MaxConns int           `config:"maxconns" default:"10"`
+		Timeout  time.Duration `default:"30s"`
+	}
+}
+
+func (c *TestConfig) Validate() error {
+	if c.Database.MaxConns <= 0 {
+		return errors.New("max conns must be positive")
+	}
+	return nil
+}
+
+func TestLoadConfig(t *testing.T) {
+	dix.Reset()
+
+	t.Setenv("TEST_DATABASE_MAXCONNS", "20")
+
+	envFile := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(envFile, []byte("# comment\nTEST_NAME=\"from env file\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := dix.LoadConfig[TestConfig](
+		dix.JSONBytes([]byte(`{"name": "from json", "database": {"dsn": "postgres://main"}}`)),
+		dix.EnvFile(envFile, "TEST_"),
+		dix.Env("TEST_"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected LoadConfig() err: got %v, want %v", err, nil)
+	}
+
+	if cfg.Name != "from env file" {
+		t.Errorf("unexpected Name: got %v, want %v", cfg.Name, "from env file")
+	}
+	if cfg.Database.DSN != "postgres://main" {
+		t.Errorf("unexpected DSN: got %v, want %v", cfg.Database.DSN, "postgres://main")
+	}
+	if cfg.Database.MaxConns != 20 {
+		t.Errorf("unexpected MaxConns: got %v, want %v", cfg.Database.MaxConns, 20)
+	}
+	if cfg.Database.Timeout != 30*time.Second {
+		t.Errorf("unexpected Timeout: got %v, want %v", cfg.Database.Timeout, 30*time.Second)
+	}
+
+	cfgFromC, err := dix.GetByKey[TestConfig](dix.ConfigValueKey)
+	if err != nil {
+		t.Errorf("unexpected GetByKey() err: got %v, want %v", err, nil)
+	}
+	if cfgFromC.Database.DSN != "postgres://main" {
+		t.Errorf("unexpected DSN: got %v, want %v", cfgFromC.Database.DSN, "postgres://main")
+	}
+	if dix.Exist[TestConfig]() {
+		t.Errorf("unexpected Exist(): got %v, want %v without WithConfigSetDefault", true, false)
+	}
+
+	var tmp struct {
+		DSN      string `di:"config:database.dsn"`
+		MaxConns int64  `di:"config:database.maxconns"`
+	}
+	err = dix.InjectStruct(&tmp)
+	if err != nil {
+		t.Errorf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+	if tmp.DSN != "postgres://main" || tmp.MaxConns != 20 {
+		t.Errorf("unexpected InjectStruct(): got %+v", tmp)
+	}
+}
+
+func TestLoadConfigRequired(t *testing.T) {
+	_, err := dix.LoadConfig[TestConfig](dix.JSONBytes([]byte(`{}`)))
+	if !errors.Is(err, dix.ErrConfigRequired) {
+		t.Errorf("unexpected LoadConfig() err: got %v, want %v", err, dix.ErrConfigRequired)
+	}
+
+	_, err = dix.LoadConfig[TestConfig](dix.JSONBytes([]byte(`{"database": {"dsn": "x", "maxconns": -1}}`)))
+	if err == nil {
+		t.Errorf("unexpected LoadConfig() err: got %v, want validation error", err)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	dix.Reset()
+
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(`{"database": {"dsn": "old"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := dix.LoadConfigWithOptions[*TestConfig]([]dix.ConfigSource{dix.JSONFile(file)}, dix.WithConfigSetDefault())
+	if err != nil {
+		t.Fatalf("unexpected LoadConfig() err: got %v, want %v", err, nil)
+	}
+
+	var reloaded dix.AfterConfigReloadCtx
+	defer dix.AfterConfigReload(func(ctx dix.AfterConfigReloadCtx) {
+		reloaded = ctx
+	})()
+
+	if err := os.WriteFile(file, []byte(`{"database": {"dsn": "new"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err = dix.ReloadConfig[*TestConfig]()
+	if err != nil {
+		t.Fatalf("unexpected ReloadConfig() err: got %v, want %v", err, nil)
+	}
+
+	cfg := dix.MustGet[*TestConfig]()
+	if cfg.Database.DSN != "new" {
+		t.Errorf("unexpected DSN: got %v, want %v", cfg.Database.DSN, "new")
+	}
+	if keyed := dix.MustGetByKey[*TestConfig](dix.ConfigValueKey); keyed != cfg {
+		t.Errorf("unexpected MustGetByKey(): got %p, want the default %p", keyed, cfg)
+	}
+	if reloaded.Old.(*TestConfig).Database.DSN != "old" || reloaded.New.(*TestConfig).Database.DSN != "new" {
+		t.Errorf("unexpected AfterConfigReloadCtx: got %+v", reloaded)
+	}
+}
+
+func TestLoadConfigKeepsDefault(t *testing.T) {
+	dix.Reset()
+
+	own := &TestConfig{Name: "own"}
+	if err := dix.Add("own", own, dix.WithValueSetDefault()); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if _, err := dix.LoadConfig[*TestConfig](dix.JSONBytes([]byte(`{"database": {"dsn": "x"}}`))); err != nil {
+		t.Fatalf("unexpected LoadConfig() err: got %v, want %v", err, nil)
+	}
+	if cfg := dix.MustGet[*TestConfig](); cfg != own {
+		t.Errorf("unexpected MustGet(): got %+v, want %+v", cfg, own)
+	}
+}
+
+type TestOtherConfig struct {
+	Database struct {
+		DSN string `config:"dsn"`
+	}
+}
+
+func TestConfigLeafLoadOrder(t *testing.T) {
+	dix.Reset()
+
+	if _, err := dix.LoadConfig[*TestConfig](dix.JSONBytes([]byte(`{"database": {"dsn": "first"}}`))); err != nil {
+		t.Fatalf("unexpected LoadConfig() err: got %v, want %v", err, nil)
+	}
+	if _, err := dix.LoadConfig[*TestOtherConfig](dix.JSONBytes([]byte(`{"database": {"dsn": "second"}}`))); err != nil {
+		t.Fatalf("unexpected LoadConfig() err: got %v, want %v", err, nil)
+	}
+
+	// the config loaded first wins, every time
+	for i := 0; i < 20; i++ {
+		var tmp struct {
+			DSN string `di:"config:database.dsn"`
+		}
+		if err := dix.InjectStruct(&tmp); err != nil || tmp.DSN != "first" {
+			t.Fatalf("unexpected InjectStruct(): got %q, %v, want %q", tmp.DSN, err, "first")
+		}
+	}
+}
+
+type TestEnvConfig struct {
+	Database struct {
+		MaxConns int `config:"max_conns"`
+	}
+	Name string
+}
+
+func TestEnvPrefix(t *testing.T) {
+	dix.Reset()
+
+	t.Setenv("TESTENV_DATABASE_MAX__CONNS", "5")
+	t.Setenv("TESTENV_NAME", "env")
+	t.Setenv("TESTENVNAME", "other")
+
+	// the "_" after the prefix is implied
+	for _, prefix := range []string{"TESTENV", "TESTENV_"} {
+		cfg, err := dix.LoadConfig[TestEnvConfig](dix.Env(prefix))
+		if err != nil {
+			t.Fatalf("unexpected LoadConfig() err: got %v, want %v", err, nil)
+		}
+		if cfg.Database.MaxConns != 5 || cfg.Name != "env" {
+			t.Errorf("unexpected LoadConfig() with prefix %q: got %+v", prefix, cfg)
+		}
+	}
+}
+
+func TestLoadConfigMapKeys(t *testing.T) {
+	dix.Reset()
+
+	type config struct {
+		Client struct {
+			Headers map[string]string
+		}
+	}
+	cfg, err := dix.LoadConfig[config](
+		dix.JSONBytes([]byte(`{"Client": {"Headers": {"X-Token": "a", "Accept": "json"}}}`)),
+		dix.JSONBytes([]byte(`{"client": {"headers": {"x-token": "b"}}}`)),
+	)
+	if err != nil {
+		t.Fatalf("unexpected LoadConfig() err: got %v, want %v", err, nil)
+	}
+
+	want := map[string]string{"X-Token": "b", "Accept": "json"}
+	if len(cfg.Client.Headers) != len(want) || cfg.Client.Headers["X-Token"] != want["X-Token"] || cfg.Client.Headers["Accept"] != want["Accept"] {
+		t.Errorf("unexpected Headers: got %v, want %v", cfg.Client.Headers, want)
+	}
+}
@@ -0,0 +1,180 @@
+package dix_test
+
+import (
+	"encoding/json"
+	htmltemplate "html/template"
+	"reflect"
+	"strings"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testDescribe struct {
+	Name string
+}
+
+func TestDescribe(t *testing.T) {
+	dix.Reset()
+
+	if err := dix.Add("primary", &testDescribe{Name: "primary"}, dix.WithValueSetDefault(), dix.WithValueTag(map[string]any{"env": "prod"})); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.Add("replica", &testDescribe{Name: "replica"}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.AddProvider("lazy", func() (*testDescribe, error) {
+		return &testDescribe{Name: "lazy"}, nil
+	}); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+
+	d := dix.Describe()
+	want := "*" + reflect.TypeOf(testDescribe{}).PkgPath() + ".testDescribe"
+	if len(d.Types) != 1 || d.Types[0].Type != want {
+		t.Fatalf("unexpected Describe() types: got %+v, want %v", d.Types, want)
+	}
+	values := d.Types[0].Values
+	if len(values) != 2 || values[0].Key != "primary" || values[1].Key != "replica" {
+		t.Fatalf("unexpected Describe() values: got %+v, want keys %v", values, []string{"primary", "replica"})
+	}
+	if !values[0].IsDefault || values[1].IsDefault {
+		t.Errorf("unexpected Describe() default alias: got %v, %v, want %v, %v", values[0].IsDefault, values[1].IsDefault, true, false)
+	}
+	if values[0].Tags["env"] != "prod" || values[0].Value != "&{Name:primary}" {
+		t.Errorf("unexpected Describe() value: got %+v", values[0])
+	}
+	providers := d.Types[0].Providers
+	if len(providers) != 1 || providers[0].HasCachedValue || providers[0].CachedValue != "" {
+		t.Fatalf("unexpected Describe() providers before run: got %+v", providers)
+	}
+
+	// Describe itself never runs providers
+	if _, err := dix.GetProviderByKey[*testDescribe]("lazy"); err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	providers = dix.Describe().Types[0].Providers
+	if !providers[0].HasCachedValue || !providers[0].IsAccessed || providers[0].CachedValue != "&{Name:lazy}" {
+		t.Errorf("unexpected Describe() providers after run: got %+v", providers)
+	}
+}
+
+func TestDescribeDefaultOnly(t *testing.T) {
+	dix.Reset()
+
+	// the default key outlives the key it was set through
+	if err := dix.Add("primary", &testDescribe{Name: "primary"}, dix.WithValueSetDefault()); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.DeleteByKey[*testDescribe]("primary"); err != nil {
+		t.Fatalf("unexpected DeleteByKey() err: got %v, want %v", err, nil)
+	}
+	values := dix.Describe().Types[0].Values
+	if len(values) != 1 || values[0].Key != dix.DefaultValueKey || !values[0].IsDefault {
+		t.Errorf("unexpected Describe() values: got %+v, want the default key", values)
+	}
+}
+
+func TestDescribeRedact(t *testing.T) {
+	dix.Reset()
+
+	if err := dix.Add("password", "hunter2", dix.WithValueTag(map[string]any{dix.SecretTag: true})); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.Add("token", "t0ken"); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.Add("name", "dix"); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.AddProvider("apiKey", func() (string, error) {
+		return "s3cret", nil
+	}, dix.WithProviderTag(map[string]any{dix.SecretTag: true})); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+	if _, err := dix.GetProviderByKey[string]("apiKey"); err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+
+	defer dix.Redact(func(ctx dix.RedactCtx) bool {
+		return ctx.ValueKey != nil && *ctx.ValueKey == "token"
+	})()
+	// a panicking subscriber redacts too
+	defer dix.Redact(func(ctx dix.RedactCtx) bool {
+		if ctx.Value == "t0ken" {
+			panic("redact")
+		}
+		return false
+	})()
+
+	d := dix.Describe()
+	text := d.String()
+	b, err := d.JSON()
+	if err != nil {
+		t.Fatalf("unexpected JSON() err: got %v, want %v", err, nil)
+	}
+	for _, secret := range []string{"hunter2", "t0ken", "s3cret"} {
+		if strings.Contains(text, secret) {
+			t.Errorf("unexpected String() secret %q: got %v", secret, text)
+		}
+		if strings.Contains(string(b), secret) {
+			t.Errorf("unexpected JSON() secret %q: got %s", secret, b)
+		}
+	}
+	if !strings.Contains(text, "= dix\n") || strings.Count(text, dix.RedactedValue) != 3 {
+		t.Errorf("unexpected String(): got %v, want 3 redacted values and %q", text, "dix")
+	}
+
+	var decoded dix.Description
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatalf("unexpected json.Unmarshal() err: got %v, want %v", err, nil)
+	}
+	if len(decoded.Types) != 1 || len(decoded.Types[0].Values) != 3 || !decoded.Types[0].Providers[0].Redacted {
+		t.Errorf("unexpected JSON() description: got %+v", decoded)
+	}
+}
+
+func TestDescribeContributions(t *testing.T) {
+	dix.Reset()
+
+	if err := dix.Contribute(&testDescribe{Name: "first"}, dix.WithContributeName("first")); err != nil {
+		t.Fatalf("unexpected Contribute() err: got %v, want %v", err, nil)
+	}
+	if err := dix.ContributeProvider(func() (*testDescribe, error) {
+		return &testDescribe{Name: "lazy"}, nil
+	}, dix.WithContributePriority(1)); err != nil {
+		t.Fatalf("unexpected ContributeProvider() err: got %v, want %v", err, nil)
+	}
+
+	d := dix.Describe()
+	if len(d.Types) != 1 || len(d.Types[0].Values) != 0 || len(d.Types[0].Providers) != 0 {
+		t.Fatalf("unexpected Describe() types: got %+v, want only contributions", d.Types)
+	}
+	contributions := d.Types[0].Contributions
+	if len(contributions) != 2 || !contributions[0].IsProvider || contributions[0].HasCachedValue ||
+		contributions[1].IsProvider || contributions[1].Name != "first" || contributions[1].Value != "&{Name:first}" {
+		t.Errorf("unexpected Describe() contributions: got %+v", contributions)
+	}
+	if text := d.String(); !strings.Contains(text, `contribution value "first"`) || !strings.Contains(text, `contribution provider ""`) {
+		t.Errorf("unexpected Describe() text: got %v", text)
+	}
+}
+
+func TestDescribeSameTypeName(t *testing.T) {
+	dix.Reset()
+
+	// both are *template.Template
+	if err := dix.Add("text", texttemplate.New("text")); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.Add("html", htmltemplate.New("html")); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	d := dix.Describe()
+	want := []string{"*html/template.Template", "*text/template.Template"}
+	if len(d.Types) != 2 || d.Types[0].Type != want[0] || d.Types[1].Type != want[1] {
+		t.Errorf("unexpected Describe() types: got %+v, want %v", d.Types, want)
+	}
+}
@@ -0,0 +1,88 @@
+package dix_test
+
+import (
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+func TestContribute(t *testing.T) {
+	dix.Reset()
+
+	dix.Contribute(NewTestInterface("a"), dix.WithContributeName("a"))
+	dix.Contribute(NewTestInterface("b"), dix.WithContributeName("b"), dix.WithContributePriority(10))
+	dix.ContributeProvider(func() (ITestInterface, error) {
+		return NewTestInterface("c"), nil
+	})
+
+	values, err := dix.GetSet[ITestInterface]()
+	if err != nil {
+		t.Errorf("unexpected GetSet() err: got %v, want %v", err, nil)
+	}
+
+	names := make([]string, 0, len(values))
+	for _, v := range values {
+		names = append(names, v.Name())
+	}
+	if len(names) != 3 || names[0] != "b" || names[1] != "a" || names[2] != "c" {
+		t.Errorf("unexpected GetSet() names: got %v, want %v", names, []string{"b", "a", "c"})
+	}
+
+	valueMap, err := dix.GetMap[ITestInterface]()
+	if err != nil {
+		t.Errorf("unexpected GetMap() err: got %v, want %v", err, nil)
+	}
+	if len(valueMap) != 2 || valueMap["a"].Name() != "a" || valueMap["b"].Name() != "b" {
+		t.Errorf("unexpected GetMap(): got %v", valueMap)
+	}
+}
+
+func TestInjectGroup(t *testing.T) {
+	dix.Reset()
+
+	dix.Contribute(NewTestInterface("a"))
+	dix.Contribute(NewTestInterface("b"))
+
+	var tmp struct {
+		IVals []ITestInterface `di:"group"`
+	}
+
+	err := dix.InjectStruct(&tmp)
+	if err != nil {
+		t.Errorf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+	if len(tmp.IVals) != 2 {
+		t.Errorf("unexpected len(IVals): got %v, want %v", len(tmp.IVals), 2)
+	}
+
+	err = dix.InjectFunc(func(iVals []ITestInterface) {
+		if len(iVals) != 2 {
+			t.Errorf("unexpected len(iVals): got %v, want %v", len(iVals), 2)
+		}
+	}, dix.WithInjectFuncGroup("0"))
+	if err != nil {
+		t.Errorf("unexpected InjectFunc() err: got %v, want %v", err, nil)
+	}
+}
+
+func TestContributeProviderNoProviderHooks(t *testing.T) {
+	dix.Reset()
+
+	var keys []dix.ProviderKey
+	defer dix.BeforeProviderRun(func(ctx dix.BeforeProviderRunCtx) { keys = append(keys, ctx.Key) })()
+	defer dix.AfterProviderRun(func(ctx dix.AfterProviderRunCtx) { keys = append(keys, ctx.Key) })()
+	defer dix.ProviderCacheHit(func(ctx dix.ProviderCacheHitCtx) { keys = append(keys, ctx.Key) })()
+	defer dix.OnProviderRun(func(key dix.ProviderKey, v ITestInterface) { keys = append(keys, key) })()
+
+	dix.ContributeProvider(func() (ITestInterface, error) {
+		return NewTestInterface("a"), nil
+	})
+	for i := 0; i < 2; i++ {
+		if _, err := dix.GetSet[ITestInterface](); err != nil {
+			t.Fatalf("unexpected GetSet() err: got %v, want %v", err, nil)
+		}
+	}
+	if len(keys) != 0 {
+		t.Errorf("unexpected provider hook keys: got %v, want none", keys)
+	}
+}
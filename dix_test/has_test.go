@@ -0,0 +1,66 @@
+package dix_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+func TestHas(t *testing.T) {
+	dix.Reset()
+	dix.SetSafeDelete(true)
+	defer dix.SetSafeDelete(false)
+
+	accessed := false
+	defer dix.AfterFirstAccess(func(ctx dix.AfterFirstAccessCtx) {
+		accessed = true
+	})()
+
+	if dix.Has[*testHas]("has") {
+		t.Errorf("unexpected Has() before Add(): got %v, want %v", true, false)
+	}
+	if err := dix.Add("has", &testHas{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if !dix.Has[*testHas]("has") || !dix.ExistByKey[*testHas]("has") {
+		t.Errorf("unexpected Has() after Add(): got %v, want %v", false, true)
+	}
+	if dix.Exist[*testHas]() {
+		t.Errorf("unexpected Exist() without default: got %v, want %v", true, false)
+	}
+
+	info, _ := dix.InspectValue[*testHas]("has")
+	if accessed || info.IsAccessed || info.RefCount != 0 {
+		t.Errorf("unexpected side effect of Has(): accessed %v, info %+v", accessed, info)
+	}
+
+	// nothing to wait for on delete
+	if err := dix.DeleteByKey[*testHas]("has"); err != nil {
+		t.Errorf("unexpected DeleteByKey() err: got %v, want %v", err, nil)
+	}
+}
+
+func TestHasProvider(t *testing.T) {
+	dix.Reset()
+
+	runs := 0
+	if err := dix.AddProvider("has", func() (*testHas, error) {
+		runs++
+		return nil, errors.New("factory")
+	}, dix.WithProviderSetDefault()); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+
+	if !dix.HasProvider[*testHas]("has") || !dix.ProviderExistByKey[*testHas]("has") || !dix.ProviderExist[*testHas]() {
+		t.Errorf("unexpected HasProvider(): got %v, want %v", false, true)
+	}
+	if dix.HasProvider[*testHas]("missing") {
+		t.Errorf("unexpected HasProvider() of missing key: got %v, want %v", true, false)
+	}
+	if runs != 0 {
+		t.Errorf("unexpected factory runs: got %v, want %v", runs, 0)
+	}
+}
+
+type testHas struct{}
@@ -0,0 +1,149 @@
+package dix_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+func TestHookProviderRunEvents(t *testing.T) {
+	dix.Reset()
+
+	var events []string
+	defer dix.BeforeProviderRun(func(ctx dix.BeforeProviderRunCtx) {
+		events = append(events, "before:"+string(ctx.Key))
+	})()
+	defer dix.ProviderCacheHit(func(ctx dix.ProviderCacheHitCtx) {
+		events = append(events, "hit:"+string(ctx.Key))
+	})()
+	var failed dix.ProviderRunFailedCtx
+	defer dix.ProviderRunFailed(func(ctx dix.ProviderRunFailedCtx) {
+		events = append(events, "failed:"+string(ctx.Key))
+		failed = ctx
+	})()
+
+	errFactory := errors.New("factory")
+	if err := dix.AddProvider[*testHook]("ok", func() (*testHook, error) {
+		return &testHook{}, nil
+	}); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+	if err := dix.AddProvider[*testHook]("fail", func() (*testHook, error) {
+		return nil, errFactory
+	}, dix.WithProviderTag(map[string]any{"team": "core"})); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := dix.GetProviderByKey[*testHook]("ok"); err != nil {
+			t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+		}
+	}
+	if _, err := dix.GetProviderByKey[*testHook]("fail"); !errors.Is(err, errFactory) {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, errFactory)
+	}
+
+	want := []string{"before:ok", "hit:ok", "before:fail", "failed:fail"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("unexpected events: got %v, want %v", events, want)
+	}
+	if !errors.Is(failed.Err, errFactory) || failed.Tags["team"] != "core" || failed.Type != reflect.TypeOf(&testHook{}) {
+		t.Errorf("unexpected ProviderRunFailedCtx: got %+v", failed)
+	}
+}
+
+func TestHookDeleteAndCloseEvents(t *testing.T) {
+	dix.Reset()
+
+	var events []string
+	defer dix.BeforeClose(func(ctx dix.BeforeCloseCtx) {
+		events = append(events, "before close:"+string(*ctx.ValueKey))
+	})()
+	var closed dix.AfterCloseCtx
+	defer dix.AfterClose(func(ctx dix.AfterCloseCtx) {
+		events = append(events, "after close:"+string(*ctx.ValueKey))
+		closed = ctx
+	})()
+	var deleted dix.AfterDeleteCtx
+	defer dix.AfterDelete(func(ctx dix.AfterDeleteCtx) {
+		events = append(events, "delete:"+string(*ctx.ValueKey))
+		deleted = ctx
+	})()
+
+	errClose := errors.New("close")
+	if err := dix.Add[*testHook]("closer", &testHook{}, dix.WithValueOnCloseCtx(func(ctx context.Context) error {
+		return errClose
+	})); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.Add[*testHook]("plain", &testHook{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	if err := dix.DeleteByKey[*testHook]("closer"); !errors.Is(err, errClose) {
+		t.Fatalf("unexpected DeleteByKey() err: got %v, want %v", err, errClose)
+	}
+	if !errors.Is(closed.Err, errClose) || !errors.Is(deleted.Err, errClose) {
+		t.Errorf("unexpected hook err: got %v and %v, want %v", closed.Err, deleted.Err, errClose)
+	}
+
+	// nothing to close
+	if err := dix.DeleteByKey[*testHook]("plain"); err != nil {
+		t.Fatalf("unexpected DeleteByKey() err: got %v, want %v", err, nil)
+	}
+
+	want := []string{"before close:closer", "after close:closer", "delete:closer", "delete:plain"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("unexpected events: got %v, want %v", events, want)
+	}
+}
+
+func TestHookResetEvents(t *testing.T) {
+	dix.Reset()
+
+	var events []string
+	defer dix.BeforeReset(func(ctx dix.BeforeResetCtx) {
+		events = append(events, "before reset")
+	})()
+	defer dix.BeforeClose(func(ctx dix.BeforeCloseCtx) {
+		if ctx.ProviderKey != nil {
+			events = append(events, "before close:"+string(*ctx.ProviderKey))
+		}
+	})()
+	var reset dix.AfterResetCtx
+	defer dix.AfterReset(func(ctx dix.AfterResetCtx) {
+		events = append(events, "after reset")
+		reset = ctx
+	})()
+
+	errClose := errors.New("close")
+	if err := dix.AddProvider[*testHookPreCloser]("provider", func() (*testHookPreCloser, error) {
+		return &testHookPreCloser{err: errClose}, nil
+	}); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+	// only cached values are closed
+	if _, err := dix.GetProviderByKey[*testHookPreCloser]("provider"); err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+
+	errs := dix.Reset()
+	want := []string{"before reset", "before close:provider", "after reset"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("unexpected events: got %v, want %v", events, want)
+	}
+	if len(reset.Errs) != len(errs) || len(errs) != 1 || !errors.Is(reset.Errs[0], errClose) {
+		t.Errorf("unexpected AfterResetCtx errs: got %v, want %v", reset.Errs, errs)
+	}
+}
+
+type testHookPreCloser struct {
+	err error
+}
+
+func (p *testHookPreCloser) PreClose(ctx context.Context) error {
+	return p.err
+}
@@ -0,0 +1,195 @@
+package dix_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jbterrylin/dix"
+)
+
+func TestHookMultipleSubscribers(t *testing.T) {
+	dix.Reset()
+
+	var calls []string
+	unsubscribeA := dix.AfterAdd(func(ctx dix.AfterAddCtx) {
+		calls = append(calls, "a")
+	})
+	defer unsubscribeA()
+	unsubscribeB := dix.AfterAdd(func(ctx dix.AfterAddCtx) {
+		calls = append(calls, "b")
+	})
+	defer unsubscribeB()
+
+	if err := dix.Add[*testHook]("hook", &testHook{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if !reflect.DeepEqual(calls, []string{"a", "b"}) {
+		t.Errorf("unexpected AfterAdd() calls: got %v, want %v", calls, []string{"a", "b"})
+	}
+
+	// unsubscribing twice must not remove another subscriber
+	unsubscribeA()
+	unsubscribeA()
+	calls = nil
+	if err := dix.Add[*testHook]("hook2", &testHook{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if !reflect.DeepEqual(calls, []string{"b"}) {
+		t.Errorf("unexpected AfterAdd() calls after unsubscribe: got %v, want %v", calls, []string{"b"})
+	}
+}
+
+func TestHookPanicIsolation(t *testing.T) {
+	dix.Reset()
+
+	var recovered []string
+	dix.SetHookPanicHandler(func(hook string, r any) {
+		recovered = append(recovered, hook)
+	})
+	defer dix.SetHookPanicHandler(nil)
+
+	called := false
+	defer dix.AfterAdd(func(ctx dix.AfterAddCtx) {
+		panic("faulty subscriber")
+	})()
+	defer dix.AfterAdd(func(ctx dix.AfterAddCtx) {
+		called = true
+	})()
+
+	if err := dix.Add[*testHook]("hook", &testHook{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if !called {
+		t.Errorf("unexpected AfterAdd() called: got %v, want %v", called, true)
+	}
+	if !reflect.DeepEqual(recovered, []string{"AfterAdd"}) {
+		t.Errorf("unexpected hook panic handler calls: got %v, want %v", recovered, []string{"AfterAdd"})
+	}
+	if _, err := dix.GetByKey[*testHook]("hook"); err != nil {
+		t.Errorf("unexpected GetByKey() err: got %v, want %v", err, nil)
+	}
+}
+
+func TestHookBeforeDuplicateRegisterStopsAtFirstErr(t *testing.T) {
+	dix.Reset()
+
+	errVeto := errors.New("veto")
+	var calls []string
+	defer dix.BeforeDuplicateRegister(func(ctx dix.BeforeDuplicateRegisterCtx) error {
+		calls = append(calls, "first")
+		return nil
+	})()
+	defer dix.BeforeDuplicateRegister(func(ctx dix.BeforeDuplicateRegisterCtx) error {
+		calls = append(calls, "veto")
+		return errVeto
+	})()
+	defer dix.BeforeDuplicateRegister(func(ctx dix.BeforeDuplicateRegisterCtx) error {
+		calls = append(calls, "last")
+		return nil
+	})()
+
+	if err := dix.Add[*testHook]("hook", &testHook{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.Add[*testHook]("hook", &testHook{}); !errors.Is(err, errVeto) {
+		t.Errorf("unexpected Add() err: got %v, want %v", err, errVeto)
+	}
+	if !reflect.DeepEqual(calls, []string{"first", "veto"}) {
+		t.Errorf("unexpected BeforeDuplicateRegister() calls: got %v, want %v", calls, []string{"first", "veto"})
+	}
+}
+
+func TestHookBeforeDuplicateRegisterPanic(t *testing.T) {
+	dix.Reset()
+
+	var recovered []string
+	dix.SetHookPanicHandler(func(hook string, r any) {
+		recovered = append(recovered, hook)
+	})
+	defer dix.SetHookPanicHandler(nil)
+
+	// a panic rejects the registration, rather than letting it through unchecked
+	defer dix.BeforeDuplicateRegister(func(ctx dix.BeforeDuplicateRegisterCtx) error {
+		panic("faulty subscriber")
+	})()
+
+	old := &testHook{}
+	if err := dix.Add("hook", old); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.Add("hook", &testHook{}); !errors.Is(err, dix.ErrHookPanic) {
+		t.Errorf("unexpected Add() err: got %v, want %v", err, dix.ErrHookPanic)
+	}
+	if v := dix.MustGetByKey[*testHook]("hook"); v != old {
+		t.Errorf("unexpected MustGetByKey() value: got %p, want the old one %p", v, old)
+	}
+	if !reflect.DeepEqual(recovered, []string{"BeforeDuplicateRegister"}) {
+		t.Errorf("unexpected hook panic handler calls: got %v, want %v", recovered, []string{"BeforeDuplicateRegister"})
+	}
+}
+
+func TestHookNilClears(t *testing.T) {
+	dix.Reset()
+
+	calls := 0
+	dix.AfterAdd(func(ctx dix.AfterAddCtx) { calls++ })
+	dix.AfterAdd(func(ctx dix.AfterAddCtx) { calls++ })
+	dix.AfterAdd(nil)
+
+	if err := dix.Add("hook", &testHook{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if calls != 0 {
+		t.Errorf("unexpected AfterAdd() calls after clearing: got %v, want %v", calls, 0)
+	}
+}
+
+func TestHookNilKeepsInternal(t *testing.T) {
+	dix.Reset()
+
+	added := 0
+	defer dix.OnAdd(func(key dix.ValueKey, v *testHook) { added++ })()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	awaited := make(chan error, 1)
+	go func() {
+		_, err := dix.Await[*testHook](ctx, "hook")
+		awaited <- err
+	}()
+	// let Await subscribe before clearing
+	time.Sleep(10 * time.Millisecond)
+	dix.AfterAdd(nil)
+
+	if err := dix.Add("hook", &testHook{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := <-awaited; err != nil {
+		t.Errorf("unexpected Await() err: got %v, want %v", err, nil)
+	}
+	if added != 1 {
+		t.Errorf("unexpected OnAdd() calls after clearing: got %v, want %v", added, 1)
+	}
+}
+
+func TestHookConcurrentSubscribe(t *testing.T) {
+	dix.Reset()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			dix.AfterAdd(func(ctx dix.AfterAddCtx) {})()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if err := dix.Add[*testHook]("hook", &testHook{}); err != nil {
+			t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+		}
+	}
+	<-done
+}
+
+type testHook struct{}
@@ -0,0 +1,89 @@
+package dix_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+func TestOnAdd(t *testing.T) {
+	dix.Reset()
+
+	var keys []dix.ValueKey
+	defer dix.OnAdd(func(key dix.ValueKey, v *testTyped) {
+		if v == nil {
+			t.Errorf("unexpected OnAdd() value: got %v", v)
+		}
+		keys = append(keys, key)
+	})()
+
+	if err := dix.Add("a", &testTyped{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	// another type
+	if err := dix.Add("b", &testHook{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	// providers have no value yet
+	if err := dix.AddProvider("c", func() (*testTyped, error) { return &testTyped{}, nil }); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+
+	if !reflect.DeepEqual(keys, []dix.ValueKey{"a"}) {
+		t.Errorf("unexpected OnAdd() keys: got %v, want %v", keys, []dix.ValueKey{"a"})
+	}
+}
+
+func TestOnProviderRun(t *testing.T) {
+	dix.Reset()
+
+	var keys []dix.ProviderKey
+	defer dix.OnProviderRun(func(key dix.ProviderKey, v *testTyped) {
+		keys = append(keys, key)
+	})()
+
+	if err := dix.AddProvider("a", func() (*testTyped, error) { return &testTyped{}, nil }); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+	if err := dix.AddProvider("b", func() (*testHook, error) { return &testHook{}, nil }); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+	_, _ = dix.GetProviderByKey[*testTyped]("a")
+	_, _ = dix.GetProviderByKey[*testTyped]("a") // cached
+	_, _ = dix.GetProviderByKey[*testTyped]("a", dix.WithProviderReload())
+	_, _ = dix.GetProviderByKey[*testHook]("b")
+
+	if !reflect.DeepEqual(keys, []dix.ProviderKey{"a", "a"}) {
+		t.Errorf("unexpected OnProviderRun() keys: got %v, want %v", keys, []dix.ProviderKey{"a", "a"})
+	}
+}
+
+func TestOnReplace(t *testing.T) {
+	dix.Reset()
+
+	type replace struct{ old, new int }
+	var replaces []replace
+	defer dix.OnReplace(func(old, new *testTyped) {
+		replaces = append(replaces, replace{old.id, new.id})
+	})()
+
+	for i := 1; i <= 3; i++ {
+		if err := dix.Add("a", &testTyped{id: i}, dix.WithValueSetDefault()); err != nil {
+			t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+		}
+	}
+	// replaces the default key only
+	if err := dix.Add("b", &testTyped{id: 4}, dix.WithValueSetDefault()); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	want := []replace{{1, 2}, {2, 3}, {3, 4}}
+	if !reflect.DeepEqual(replaces, want) {
+		t.Errorf("unexpected OnReplace() calls: got %v, want %v", replaces, want)
+	}
+}
+
+type testTyped struct {
+	id int
+}
@@ -0,0 +1,99 @@
+package dix_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testFallback struct {
+	Store    *Test         `di:"key:replica|main|"`
+	Provider *Test         `di:"type:provider;key:replica|main"`
+	Timeout  time.Duration `di:"config:server.timeout;default:30s"`
+	Name     string        `di:"key:name;default:unknown"`
+}
+
+func TestInjectStructFallbackKey(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("default"), dix.WithValueSetDefault())
+	dix.AddProvider("main", func() (*Test, error) {
+		return NewTest("main provider"), nil
+	})
+
+	var tmp testFallback
+	if err := dix.InjectStruct(&tmp); err != nil {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+	if tmp.Store.Name() != "default" {
+		t.Errorf("unexpected Store.Name(): got %v, want %v", tmp.Store.Name(), "default")
+	}
+	if tmp.Provider.Name() != "main provider" {
+		t.Errorf("unexpected Provider.Name(): got %v, want %v", tmp.Provider.Name(), "main provider")
+	}
+	if tmp.Timeout != 30*time.Second {
+		t.Errorf("unexpected Timeout: got %v, want %v", tmp.Timeout, 30*time.Second)
+	}
+	if tmp.Name != "unknown" {
+		t.Errorf("unexpected Name: got %v, want %v", tmp.Name, "unknown")
+	}
+
+	dix.Add("main", NewTest("main"))
+	dix.Add("name", "test")
+	tmp = testFallback{}
+	if err := dix.InjectStruct(&tmp); err != nil {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+	if tmp.Store.Name() != "main" {
+		t.Errorf("unexpected Store.Name(): got %v, want %v", tmp.Store.Name(), "main")
+	}
+	if tmp.Name != "test" {
+		t.Errorf("unexpected Name: got %v, want %v", tmp.Name, "test")
+	}
+
+	dix.Reset()
+	dix.AddProvider("main", func() (*Test, error) {
+		return NewTest("main provider"), nil
+	})
+	tmp = testFallback{}
+	if err := dix.InjectStruct(&tmp); !errors.Is(err, dix.ErrValueNotFound) {
+		t.Errorf("unexpected InjectStruct() err: got %v, want %v", err, dix.ErrValueNotFound)
+	}
+}
+
+func TestInjectFuncFallbackKey(t *testing.T) {
+	dix.Reset()
+	dix.Add("main", NewTest("main"))
+
+	err := dix.InjectFunc(func(test *Test, timeout time.Duration) {
+		if test.Name() != "main" {
+			t.Errorf("unexpected test.Name(): got %v, want %v", test.Name(), "main")
+		}
+		if timeout != time.Minute {
+			t.Errorf("unexpected timeout: got %v, want %v", timeout, time.Minute)
+		}
+	},
+		dix.WithInjectFuncKeys("0", "replica", "main", ""),
+		dix.WithInjectFuncDefault("1", "1m"),
+	)
+	if err != nil {
+		t.Errorf("unexpected InjectFunc() err: got %v, want %v", err, nil)
+	}
+}
+
+type testInvalidDefaultStruct struct {
+	Timeout time.Duration `di:"default:soon"`
+}
+
+func TestInjectStructInvalidDefault(t *testing.T) {
+	dix.Reset()
+
+	var tmp testInvalidDefaultStruct
+	if err := dix.InjectStruct(&tmp); err == nil {
+		t.Errorf("unexpected InjectStruct() err: got %v, want an error", err)
+	}
+	if err := dix.ValidateStruct[testInvalidDefaultStruct](); !errors.Is(err, dix.ErrInvalidTag) {
+		t.Errorf("unexpected ValidateStruct() err: got %v, want %v", err, dix.ErrInvalidTag)
+	}
+}
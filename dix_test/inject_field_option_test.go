@@ -0,0 +1,128 @@
+package dix_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testFieldOption struct {
+	Store  *Test
+	Logger ITestInterface `di:"key:missing"`
+	Nested struct {
+		Store *Test
+	}
+}
+
+func TestInjectStructFieldOption(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+	dix.AddProvider(TestProviderKey, func() (*Test, error) {
+		return NewTest("main"), nil
+	})
+
+	var tmp testFieldOption
+	err := dix.InjectStruct(&tmp,
+		dix.Field("Store", dix.WithKey(string(TestProviderKey)), dix.FromProvider()),
+		dix.SkipField("Logger"),
+		dix.Field("Nested", dix.WithInline()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+
+	if tmp.Store.Name() != "main" {
+		t.Errorf("unexpected Store.Name(): got %v, want %v", tmp.Store.Name(), "main")
+	}
+	if tmp.Logger != nil {
+		t.Errorf("unexpected Logger: got %v, want %v", tmp.Logger, nil)
+	}
+	if tmp.Nested.Store.Name() != "test" {
+		t.Errorf("unexpected Nested.Store.Name(): got %v, want %v", tmp.Nested.Store.Name(), "test")
+	}
+}
+
+func TestInjectStructFieldOptionOverrideTag(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+	dix.Add(TestInterfaceKey, NewTestInterface("test interface"))
+
+	var tmp testFieldOption
+	err := dix.InjectStruct(&tmp,
+		dix.Field("Logger", dix.WithKey(string(TestInterfaceKey))),
+		dix.SkipField("Nested"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+
+	if tmp.Logger.Name() != "test interface" {
+		t.Errorf("unexpected Logger.Name(): got %v, want %v", tmp.Logger.Name(), "test interface")
+	}
+}
+
+func TestInjectStructFieldOptionNotFound(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+
+	var tmp testFieldOption
+	err := dix.InjectStruct(&tmp, dix.SkipField("Logger"), dix.SkipField("Nested"), dix.Field("Unknown", dix.WithOptional()))
+	if !errors.Is(err, dix.ErrFieldNotFound) {
+		t.Errorf("unexpected InjectStruct() err: got %v, want %v", err, dix.ErrFieldNotFound)
+	}
+}
+
+type testFieldOptionOpposite struct {
+	Provided *Test `di:"type:provider;key:provider"`
+	Keyed    *Test `di:"key:missing"`
+	Optional *Test `di:"key:missing;optional"`
+}
+
+func TestInjectStructFieldOptionOppositeTag(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+	dix.AddProvider("provider", func() (*Test, error) {
+		return NewTest("provider"), nil
+	})
+
+	var tmp testFieldOptionOpposite
+	err := dix.InjectStruct(&tmp,
+		dix.Field("Provided", dix.FromValue(), dix.WithKey(string(TestKey))),
+		dix.Field("Keyed", dix.WithDefaultKey()),
+		dix.Field("Optional", dix.WithRequired()),
+	)
+	if !errors.Is(err, dix.ErrValueNotFound) {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, dix.ErrValueNotFound)
+	}
+
+	tmp = testFieldOptionOpposite{}
+	err = dix.InjectStruct(&tmp,
+		dix.Field("Provided", dix.FromValue(), dix.WithKey(string(TestKey))),
+		dix.Field("Keyed", dix.WithDefaultKey()),
+		dix.Field("Optional", dix.WithRequired(), dix.WithDefaultKey()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+	for name, val := range map[string]*Test{"Provided": tmp.Provided, "Keyed": tmp.Keyed, "Optional": tmp.Optional} {
+		if val == nil || val.Name() != "test" {
+			t.Errorf("unexpected %v: got %v, want %v", name, val, "test")
+		}
+	}
+}
+
+func TestInjectStructFieldOptionNotFoundSorted(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+
+	for i := 0; i < 20; i++ {
+		var tmp testFieldOption
+		err := dix.InjectStruct(&tmp, dix.SkipField("Logger"), dix.SkipField("Nested"),
+			dix.Field("Unknown3"), dix.SkipField("Unknown2"), dix.Field("Unknown1"), dix.SkipField("Unknown4"))
+		want := "failed at field name=Unknown1: " + dix.ErrFieldNotFound.Error()
+		if err == nil || err.Error() != want {
+			t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, want)
+		}
+	}
+}
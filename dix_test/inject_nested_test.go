@@ -0,0 +1,106 @@
+package dix_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testAuth struct {
+	Store *Test
+}
+
+type testHandlers struct {
+	Auth *testAuth `di:"inline"`
+}
+
+type TestEmbedded struct {
+	IVal ITestInterface
+}
+
+type testServer struct {
+	TestEmbedded
+	Handlers testHandlers `di:"inline"`
+}
+
+func TestInjectStructNested(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+	dix.Add(TestInterfaceKey, NewTestInterface("test interface"), dix.WithValueSetDefault())
+
+	var tmp struct {
+		Server *testServer `di:"inline"`
+	}
+
+	err := dix.InjectStruct(&tmp)
+	if err != nil {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+
+	if tmp.Server.Handlers.Auth.Store.Name() != "test" {
+		t.Errorf("unexpected Store.Name(): got %v, want %v", tmp.Server.Handlers.Auth.Store.Name(), "test")
+	}
+	if tmp.Server.IVal.Name() != "test interface" {
+		t.Errorf("unexpected IVal.Name(): got %v, want %v", tmp.Server.IVal.Name(), "test interface")
+	}
+}
+
+func TestInjectStructNestedErrPath(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestInterfaceKey, NewTestInterface("test interface"), dix.WithValueSetDefault())
+
+	var tmp struct {
+		Server testServer `di:"inline"`
+	}
+
+	err := dix.InjectStruct(&tmp)
+	if !errors.Is(err, dix.ErrValueNotFound) {
+		t.Errorf("unexpected InjectStruct() err: got %v, want %v", err, dix.ErrValueNotFound)
+	}
+	if err == nil || !strings.Contains(err.Error(), "Server.Handlers.Auth.Store") {
+		t.Errorf("unexpected InjectStruct() err: got %v, want field path %v", err, "Server.Handlers.Auth.Store")
+	}
+}
+
+// TestNode is exported, so it can be set when embedded.
+type TestNode struct {
+	*TestNode
+	X int `di:"-"`
+}
+
+type testCycleA struct {
+	B *testCycleB `di:"inline"`
+}
+
+type testCycleB struct {
+	*testCycleA
+}
+
+func TestInjectStructInlineCycle(t *testing.T) {
+	dix.Reset()
+
+	if err := dix.InjectStruct(&TestNode{}); !errors.Is(err, dix.ErrInlineCycle) {
+		t.Errorf("unexpected InjectStruct() err: got %v, want %v", err, dix.ErrInlineCycle)
+	}
+
+	err := dix.InjectStruct(&testCycleA{})
+	if !errors.Is(err, dix.ErrInlineCycle) || !strings.Contains(err.Error(), "B.testCycleA") {
+		t.Errorf("unexpected InjectStruct() err: got %v, want %v at %v", err, dix.ErrInlineCycle, "B.testCycleA")
+	}
+	if err := dix.ValidateStruct[TestNode](); !errors.Is(err, dix.ErrInlineCycle) {
+		t.Errorf("unexpected ValidateStruct() err: got %v, want %v", err, dix.ErrInlineCycle)
+	}
+
+	// a tag keeps an embedded struct resolved as a value
+	var tagged struct {
+		*TestNode `di:"type:value"`
+	}
+	if err := dix.Add("node", &TestNode{X: 1}, dix.WithValueSetDefault()); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if err := dix.InjectStruct(&tagged); err != nil || tagged.TestNode == nil || tagged.X != 1 {
+		t.Errorf("unexpected InjectStruct() tagged embedded: got %v, %v, want X %v", tagged.TestNode, err, 1)
+	}
+}
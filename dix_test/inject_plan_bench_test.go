@@ -0,0 +1,75 @@
+package dix_test
+
+import (
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testBenchHandler struct {
+	Store    *Test          `di:"key:test"`
+	IVal     ITestInterface `di:"key:test interface"`
+	Provider *Test          `di:"type:provider;key:test"`
+	Optional *Test          `di:"key:missing;optional"`
+	Skipped  *Test          `di:"-"`
+}
+
+func setupInjectBench(b *testing.B, cache bool) {
+	dix.Reset()
+	dix.SetInjectPlanCache(cache)
+	b.Cleanup(func() {
+		dix.SetInjectPlanCache(true)
+	})
+
+	dix.Add(TestKey, NewTest("test"))
+	dix.Add(TestInterfaceKey, NewTestInterface("test interface"))
+	dix.AddProvider(TestProviderKey, func() (*Test, error) {
+		return NewTest("provider"), nil
+	})
+}
+
+func benchmarkInjectStruct(b *testing.B, cache bool) {
+	setupInjectBench(b, cache)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var tmp testBenchHandler
+		if err := dix.InjectStruct(&tmp); err != nil {
+			b.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+		}
+	}
+}
+
+func BenchmarkInjectStructCached(b *testing.B) {
+	benchmarkInjectStruct(b, true)
+}
+
+func BenchmarkInjectStructUncached(b *testing.B) {
+	benchmarkInjectStruct(b, false)
+}
+
+func benchmarkInjectFunc(b *testing.B, cache bool) {
+	setupInjectBench(b, cache)
+
+	fn := func(test *Test, iVal ITestInterface) error {
+		return nil
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err := dix.InjectFunc(fn, dix.WithInjectFuncKey("0", string(TestKey)), dix.WithInjectFuncKey("ITestInterface", string(TestInterfaceKey)))
+		if err != nil {
+			b.Fatalf("unexpected InjectFunc() err: got %v, want %v", err, nil)
+		}
+	}
+}
+
+func BenchmarkInjectFuncCached(b *testing.B) {
+	benchmarkInjectFunc(b, true)
+}
+
+func BenchmarkInjectFuncUncached(b *testing.B) {
+	benchmarkInjectFunc(b, false)
+}
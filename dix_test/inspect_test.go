@@ -0,0 +1,203 @@
+package dix_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jbterrylin/dix"
+)
+
+func TestInspectValue(t *testing.T) {
+	dix.Reset()
+
+	if _, err := dix.InspectValue[*testInspect]("missing"); !errors.Is(err, dix.ErrValueNotFound) {
+		t.Errorf("unexpected InspectValue() err: got %v, want %v", err, dix.ErrValueNotFound)
+	}
+
+	if err := dix.Add("inspect", &testInspect{}, dix.WithValueTag(map[string]any{"secret": true})); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	info, err := dix.InspectValue[*testInspect]("inspect")
+	if err != nil {
+		t.Fatalf("unexpected InspectValue() err: got %v, want %v", err, nil)
+	}
+	if info.Key != "inspect" || info.IsAccessed || info.CreatedAt.IsZero() || info.Tags["secret"] != true || info.HasCloseHook {
+		t.Errorf("unexpected InspectValue() info: got %+v", info)
+	}
+
+	// the snapshot is a copy
+	info.Tags["secret"] = false
+	if info, _ := dix.InspectValue[*testInspect]("inspect"); info.Tags["secret"] != true {
+		t.Errorf("unexpected InspectValue() tags: got %v, want %v", info.Tags["secret"], true)
+	}
+
+	if _, err := dix.GetByKey[*testInspect]("inspect"); err != nil {
+		t.Fatalf("unexpected GetByKey() err: got %v, want %v", err, nil)
+	}
+	if info, _ := dix.InspectValue[*testInspect]("inspect"); !info.IsAccessed || info.AccessedAt.IsZero() {
+		t.Errorf("unexpected InspectValue() info after GetByKey(): got %+v", info)
+	}
+}
+
+func TestInspectProvider(t *testing.T) {
+	dix.Reset()
+
+	runs := 0
+	if err := dix.AddProvider[*testInspect]("inspect", func() (*testInspect, error) {
+		runs++
+		return &testInspect{}, nil
+	}); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+
+	info, err := dix.InspectProvider[*testInspect]("inspect")
+	if err != nil {
+		t.Fatalf("unexpected InspectProvider() err: got %v, want %v", err, nil)
+	}
+	if runs != 0 || info.HasCachedValue || info.IsAccessed || info.NoCache {
+		t.Errorf("unexpected InspectProvider() info: got %+v, runs %v", info, runs)
+	}
+
+	if _, err := dix.GetProviderByKey[*testInspect]("inspect"); err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	if info, _ := dix.InspectProvider[*testInspect]("inspect"); !info.HasCachedValue || !info.IsAccessed {
+		t.Errorf("unexpected InspectProvider() info after GetProviderByKey(): got %+v", info)
+	}
+}
+
+func TestInspectProviderFromRunHooks(t *testing.T) {
+	dix.Reset()
+
+	inspected := make(chan string, 5)
+	inspect := func(hook string) {
+		if _, err := dix.InspectProvider[*testInspect]("inspect"); err == nil {
+			inspected <- hook
+		}
+	}
+	defer dix.BeforeProviderRun(func(ctx dix.BeforeProviderRunCtx) { inspect("BeforeProviderRun") })()
+	defer dix.AfterProviderRun(func(ctx dix.AfterProviderRunCtx) { inspect("AfterProviderRun") })()
+	defer dix.AfterFirstAccess(func(ctx dix.AfterFirstAccessCtx) { inspect("AfterFirstAccess") })()
+	defer dix.ProviderCacheHit(func(ctx dix.ProviderCacheHitCtx) { inspect("ProviderCacheHit") })()
+
+	if err := dix.AddProvider[*testInspect]("inspect", func() (*testInspect, error) {
+		return &testInspect{}, nil
+	}); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dix.GetProviderByKey[*testInspect]("inspect")
+		dix.GetProviderByKey[*testInspect]("inspect")
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("unexpected GetProviderByKey(): deadlocked with InspectProvider() in hooks")
+	}
+
+	if len(inspected) != 4 {
+		t.Errorf("unexpected inspected hooks: got %v, want %v", len(inspected), 4)
+	}
+}
+
+func TestHookInfo(t *testing.T) {
+	dix.Reset()
+
+	var added *dix.ValueInfo
+	defer dix.AfterAdd(func(ctx dix.AfterAddCtx) {
+		added = ctx.ValueInfo
+	})()
+	var old, new *dix.ValueInfo
+	defer dix.BeforeDuplicateRegister(func(ctx dix.BeforeDuplicateRegisterCtx) error {
+		if ctx.IsDefault {
+			old, new = ctx.OldValueInfo, ctx.NewValueInfo
+		}
+		return nil
+	})()
+
+	if err := dix.Add("first", &testInspect{}, dix.WithValueSetDefault()); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if added == nil || added.Key != "first" {
+		t.Errorf("unexpected AfterAddCtx.ValueInfo: got %+v", added)
+	}
+
+	if err := dix.Add("second", &testInspect{}, dix.WithValueSetDefault()); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if old == nil || new == nil || old.Key != dix.DefaultValueKey || new.Key != "second" || new.CreatedAt.Before(old.CreatedAt) {
+		t.Errorf("unexpected BeforeDuplicateRegisterCtx infos: got %+v and %+v", old, new)
+	}
+}
+
+type testInspect struct{}
+
+// withoutDeadlock fails t if f does not return within a second.
+func withoutDeadlock(t *testing.T, name string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("unexpected %v: deadlocked with Inspect in hooks", name)
+	}
+}
+
+func TestInspectValueFromAfterFirstAccess(t *testing.T) {
+	dix.Reset()
+
+	inspected := false
+	defer dix.AfterFirstAccess(func(ctx dix.AfterFirstAccessCtx) {
+		_, err := dix.InspectValue[*testInspect]("inspect")
+		inspected = err == nil
+	})()
+	if err := dix.Add("inspect", &testInspect{}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	withoutDeadlock(t, "GetByKey()", func() {
+		dix.GetByKey[*testInspect]("inspect")
+	})
+	if !inspected {
+		t.Errorf("unexpected inspected: got %v, want %v", inspected, true)
+	}
+}
+
+func TestInspectProviderFromDeleteHooks(t *testing.T) {
+	dix.Reset()
+
+	// the default key aliases the deleted provider
+	var inspected []string
+	inspect := func(hook string) {
+		if _, err := dix.InspectProvider[*testInspect](dix.DefaultProviderKey); err == nil {
+			inspected = append(inspected, hook)
+		}
+	}
+	defer dix.BeforeClose(func(ctx dix.BeforeCloseCtx) { inspect("BeforeClose") })()
+	defer dix.AfterClose(func(ctx dix.AfterCloseCtx) { inspect("AfterClose") })()
+	defer dix.AfterDelete(func(ctx dix.AfterDeleteCtx) { inspect("AfterDelete") })()
+
+	// a cleanup, so there is a close to run
+	if err := dix.Provide("inspect", func() (*testInspect, func()) {
+		return &testInspect{}, func() {}
+	}, dix.WithProviderSetDefault()); err != nil {
+		t.Fatalf("unexpected Provide() err: got %v, want %v", err, nil)
+	}
+	dix.GetProviderByKey[*testInspect]("inspect")
+
+	withoutDeadlock(t, "DeleteProviderByKey()", func() {
+		dix.DeleteProviderByKey[*testInspect]("inspect")
+	})
+	if len(inspected) != 3 {
+		t.Errorf("unexpected inspected hooks: got %v, want %v", inspected, []string{"BeforeClose", "AfterClose", "AfterDelete"})
+	}
+}
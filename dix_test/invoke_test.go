@@ -0,0 +1,46 @@
+package dix_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testCtxKey struct{}
+
+func TestInvoke(t *testing.T) {
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+
+	ctx := context.WithValue(context.Background(), testCtxKey{}, "from ctx")
+
+	name, err := dix.Invoke[string](ctx, func(ctx context.Context, val *Test) (string, error) {
+		return val.Name() + " " + ctx.Value(testCtxKey{}).(string), nil
+	})
+	if err != nil {
+		t.Errorf("unexpected Invoke() err: got %v, want %v", err, nil)
+	}
+	if name != "test from ctx" {
+		t.Errorf("unexpected Invoke(): got %v, want %v", name, "test from ctx")
+	}
+
+	_, err = dix.Invoke[int](ctx, func(val *Test) (string, error) {
+		return val.Name(), nil
+	})
+	if !errors.Is(err, dix.ErrTypeMismatch) {
+		t.Errorf("unexpected Invoke() err: got %v, want %v", err, dix.ErrTypeMismatch)
+	}
+}
+
+func TestInjectFuncReturnErr(t *testing.T) {
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+
+	errFunc := errors.New("func failed")
+	err := dix.InjectFunc(func(val *Test) error {
+		return errFunc
+	})
+	if !errors.Is(err, errFunc) {
+		t.Errorf("unexpected InjectFunc() err: got %v, want %v", err, errFunc)
+	}
+}
@@ -0,0 +1,191 @@
+package dix_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testLifecycle struct {
+	Store *Test
+
+	postInjectCount int      `di:"-"`
+	postInjectErr   error    `di:"-"`
+	events          []string `di:"-"`
+	preCloseErr     error    `di:"-"`
+}
+
+func (l *testLifecycle) PostInject(ctx context.Context) error {
+	l.postInjectCount++
+	return l.postInjectErr
+}
+
+func (l *testLifecycle) PreClose(ctx context.Context) error {
+	l.events = append(l.events, "pre close")
+	return l.preCloseErr
+}
+
+func (l *testLifecycle) Close() error {
+	l.events = append(l.events, "close")
+	return nil
+}
+
+func TestInjectStructPostInject(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+
+	tmp := &testLifecycle{}
+	if err := dix.InjectStruct(tmp); err != nil {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+	if tmp.postInjectCount != 1 {
+		t.Errorf("unexpected postInjectCount: got %v, want %v", tmp.postInjectCount, 1)
+	}
+
+	errPostInject := errors.New("post inject failed")
+	tmp = &testLifecycle{postInjectErr: errPostInject}
+	if err := dix.InjectStruct(tmp); !errors.Is(err, errPostInject) {
+		t.Errorf("unexpected InjectStruct() err: got %v, want %v", err, errPostInject)
+	}
+
+	// not called if injection fails
+	dix.Reset()
+	tmp = &testLifecycle{}
+	if err := dix.InjectStruct(tmp); !errors.Is(err, dix.ErrValueNotFound) {
+		t.Errorf("unexpected InjectStruct() err: got %v, want %v", err, dix.ErrValueNotFound)
+	}
+	if tmp.postInjectCount != 0 {
+		t.Errorf("unexpected postInjectCount: got %v, want %v", tmp.postInjectCount, 0)
+	}
+}
+
+func TestProviderPostInject(t *testing.T) {
+	dix.Reset()
+
+	errPostInject := errors.New("post inject failed")
+	dix.AddProvider(TestProviderKey, func() (*testLifecycle, error) {
+		return &testLifecycle{}, nil
+	})
+	dix.AddProvider("failed", func() (*testLifecycle, error) {
+		return &testLifecycle{postInjectErr: errPostInject}, nil
+	})
+
+	tmp, err := dix.GetProviderByKey[*testLifecycle](TestProviderKey)
+	if err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	// cached, so it is not called again
+	dix.GetProviderByKey[*testLifecycle](TestProviderKey)
+	if tmp.postInjectCount != 1 {
+		t.Errorf("unexpected postInjectCount: got %v, want %v", tmp.postInjectCount, 1)
+	}
+
+	_, err = dix.GetProviderByKey[*testLifecycle]("failed")
+	if !errors.Is(err, errPostInject) {
+		t.Errorf("unexpected GetProviderByKey() err: got %v, want %v", err, errPostInject)
+	}
+}
+
+func TestProviderPostInjectByFactory(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+
+	// PostInject is run once by InjectStructWithCtx, not again for the returned value
+	dix.AddCtxProvider(TestProviderKey, func(ctx context.Context) (*testLifecycle, error) {
+		tmp := &testLifecycle{}
+		return tmp, dix.InjectStructWithCtx(ctx, tmp)
+	})
+	// only the returned value is skipped
+	other := &testLifecycle{}
+	dix.AddCtxProvider("other", func(ctx context.Context) (*testLifecycle, error) {
+		if err := dix.InjectStructWithCtx(ctx, other); err != nil {
+			return nil, err
+		}
+		return &testLifecycle{}, nil
+	})
+
+	tmp, err := dix.GetProviderByKey[*testLifecycle](TestProviderKey)
+	if err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	if tmp.postInjectCount != 1 {
+		t.Errorf("unexpected postInjectCount: got %v, want %v", tmp.postInjectCount, 1)
+	}
+
+	tmp, err = dix.GetProviderByKey[*testLifecycle]("other")
+	if err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	if tmp.postInjectCount != 1 || other.postInjectCount != 1 {
+		t.Errorf("unexpected postInjectCount: got %v and %v, want %v", tmp.postInjectCount, other.postInjectCount, 1)
+	}
+}
+
+func TestProviderPostInjectConcurrentRun(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	dix.AddProvider("blocking", func() (*testLifecycle, error) {
+		close(started)
+		<-release
+		return &testLifecycle{}, nil
+	})
+	shared := &testLifecycle{}
+	dix.AddProvider("shared", func() (*testLifecycle, error) {
+		return shared, nil
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dix.GetProviderByKey[*testLifecycle]("blocking")
+	}()
+	<-started
+
+	// injected outside of any factory function, while another one runs
+	if err := dix.InjectStruct(shared); err != nil {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+	if _, err := dix.GetProviderByKey[*testLifecycle]("shared"); err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	close(release)
+	<-done
+
+	if shared.postInjectCount != 2 {
+		t.Errorf("unexpected postInjectCount: got %v, want %v", shared.postInjectCount, 2)
+	}
+}
+
+func TestPreClose(t *testing.T) {
+	dix.Reset()
+
+	errPreClose := errors.New("pre close failed")
+	tmp := &testLifecycle{preCloseErr: errPreClose}
+	dix.Add(TestKey, tmp)
+
+	err := dix.DeleteByKey[*testLifecycle](TestKey)
+	if !errors.Is(err, errPreClose) {
+		t.Errorf("unexpected DeleteByKey() err: got %v, want %v", err, errPreClose)
+	}
+	if len(tmp.events) != 2 || tmp.events[0] != "pre close" || tmp.events[1] != "close" {
+		t.Errorf("unexpected events: got %v, want %v", tmp.events, []string{"pre close", "close"})
+	}
+
+	provided := &testLifecycle{}
+	dix.AddProvider(TestProviderKey, func() (*testLifecycle, error) {
+		return provided, nil
+	})
+	dix.GetProviderByKey[*testLifecycle](TestProviderKey)
+
+	if err := dix.DeleteProviderByKey[*testLifecycle](TestProviderKey); err != nil {
+		t.Errorf("unexpected DeleteProviderByKey() err: got %v, want %v", err, nil)
+	}
+	if len(provided.events) != 1 || provided.events[0] != "pre close" {
+		t.Errorf("unexpected events: got %v, want %v", provided.events, []string{"pre close"})
+	}
+}
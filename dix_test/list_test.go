@@ -0,0 +1,91 @@
+package dix_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+func TestListKeysOrder(t *testing.T) {
+	dix.Reset()
+
+	dix.Add(dix.ValueKey("a"), NewTest("a"))
+	dix.Add(dix.ValueKey("b"), NewTest("b"), dix.WithValuePriority(10))
+	dix.Add(dix.ValueKey("c"), NewTest("c"))
+	dix.Add(dix.ValueKey("d"), NewTest("d"), dix.WithValueSetDefault())
+
+	want := []dix.ValueKey{"b", "a", "c", "d"}
+	for i := 0; i < 10; i++ {
+		keys := dix.ListKeys[*Test]()
+		if len(keys) != len(want) {
+			t.Fatalf("unexpected ListKeys(): got %v, want %v", keys, want)
+		}
+		for j := range keys {
+			if keys[j] != want[j] {
+				t.Fatalf("unexpected ListKeys(): got %v, want %v", keys, want)
+			}
+		}
+	}
+
+	values := dix.GetAll[*Test]()
+	for j := range values {
+		if values[j].Name() != string(want[j]) {
+			t.Errorf("unexpected GetAll()[%d].Name(): got %v, want %v", j, values[j].Name(), want[j])
+		}
+	}
+}
+
+func TestListProviderKeysOrder(t *testing.T) {
+	dix.Reset()
+
+	for _, name := range []string{"a", "b", "c"} {
+		name := name
+		opts := []dix.ProviderAddOption{}
+		if name == "c" {
+			opts = append(opts, dix.WithProviderPriority(1))
+		}
+		dix.AddProvider(dix.ProviderKey(name), func() (*Test, error) {
+			return NewTest(name), nil
+		}, opts...)
+	}
+
+	want := []dix.ProviderKey{"c", "a", "b"}
+	keys := dix.ListProviderKeys[*Test]()
+	if len(keys) != len(want) || keys[0] != want[0] || keys[1] != want[1] || keys[2] != want[2] {
+		t.Errorf("unexpected ListProviderKeys(): got %v, want %v", keys, want)
+	}
+
+	values, err := dix.GetAllProvider[*Test]()
+	if err != nil {
+		t.Errorf("unexpected GetAllProvider() err: got %v, want %v", err, nil)
+	}
+	for j := range values {
+		if values[j].Name() != string(want[j]) {
+			t.Errorf("unexpected GetAllProvider()[%d].Name(): got %v, want %v", j, values[j].Name(), want[j])
+		}
+	}
+}
+
+func TestGetAllProviderFailing(t *testing.T) {
+	dix.Reset()
+
+	errFailing := errors.New("failing")
+	dix.AddProvider(dix.ProviderKey("a"), func() (*Test, error) {
+		return NewTest("a"), nil
+	})
+	dix.AddProvider(dix.ProviderKey("failing"), func() (*Test, error) {
+		return nil, errFailing
+	})
+	dix.AddProvider(dix.ProviderKey("b"), func() (*Test, error) {
+		return NewTest("b"), nil
+	})
+
+	values, err := dix.GetAllProvider[*Test]()
+	if !errors.Is(err, errFailing) {
+		t.Errorf("unexpected GetAllProvider() err: got %v, want %v", err, errFailing)
+	}
+	if len(values) != 0 {
+		t.Errorf("unexpected GetAllProvider(): got %v, want none", values)
+	}
+}
@@ -0,0 +1,231 @@
+package dix_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testParam struct {
+	dix.In
+	Main    *Test `di:"key:main"`
+	Replica *Test `di:"key:replica"`
+	Missing *Test `di:"key:missing;optional"`
+}
+
+func TestInjectFuncIn(t *testing.T) {
+	dix.Add(dix.ValueKey("main"), NewTest("main"))
+	dix.Add(dix.ValueKey("replica"), NewTest("replica"))
+
+	err := dix.InjectFunc(func(p testParam) {
+		if p.Main.Name() != "main" {
+			t.Errorf("unexpected Main.Name(): got %v, want %v", p.Main.Name(), "main")
+		}
+		if p.Replica.Name() != "replica" {
+			t.Errorf("unexpected Replica.Name(): got %v, want %v", p.Replica.Name(), "replica")
+		}
+		if p.Missing != nil {
+			t.Errorf("unexpected Missing: got %v, want %v", p.Missing, nil)
+		}
+	})
+	if err != nil {
+		t.Errorf("unexpected InjectFunc() err: got %v, want %v", err, nil)
+	}
+}
+
+func TestProvideIn(t *testing.T) {
+	dix.Add(dix.ValueKey("main"), NewTest("main"))
+	dix.Add(dix.ValueKey("replica"), NewTest("replica"))
+
+	err := dix.Provide(dix.ProviderKey("names"), func(p testParam) ([]string, error) {
+		return []string{p.Main.Name(), p.Replica.Name()}, nil
+	})
+	if err != nil {
+		t.Errorf("unexpected Provide() err: got %v, want %v", err, nil)
+	}
+
+	names, err := dix.GetProviderByKey[[]string](dix.ProviderKey("names"))
+	if err != nil {
+		t.Errorf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	if len(names) != 2 || names[0] != "main" || names[1] != "replica" {
+		t.Errorf("unexpected GetProviderByKey(): got %v, want %v", names, []string{"main", "replica"})
+	}
+}
+
+type testOut struct {
+	dix.Out
+	Main    *Test
+	Replica *Test          `di:"key:replica"`
+	IVal    ITestInterface `di:"key:iface"`
+}
+
+func TestProvideOut(t *testing.T) {
+	dix.Reset()
+
+	runs := 0
+	cleanups := 0
+	err := dix.Provide(dix.ProviderKey("main"), func() (testOut, func(), error) {
+		runs++
+		test := NewTest("main")
+		return testOut{
+			Main:    test,
+			Replica: NewTest("replica"),
+			IVal:    test,
+		}, func() { cleanups++ }, nil
+	})
+	if err != nil {
+		t.Errorf("unexpected Provide() err: got %v, want %v", err, nil)
+	}
+
+	main, err := dix.GetProviderByKey[*Test](dix.ProviderKey("main"))
+	if err != nil {
+		t.Errorf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	replica, err := dix.GetProviderByKey[*Test](dix.ProviderKey("replica"))
+	if err != nil {
+		t.Errorf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	iVal, err := dix.GetProviderByKey[ITestInterface](dix.ProviderKey("iface"))
+	if err != nil {
+		t.Errorf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+
+	if main.Name() != "main" || replica.Name() != "replica" || iVal.Name() != "main" {
+		t.Errorf("unexpected names: got %v, %v, %v", main.Name(), replica.Name(), iVal.Name())
+	}
+	if runs != 1 {
+		t.Errorf("unexpected runs: got %v, want %v", runs, 1)
+	}
+
+	errs := dix.Reset()
+	if len(errs) != 0 {
+		t.Errorf("unexpected Reset() errs: got %v, want %v", errs, nil)
+	}
+	if cleanups != 1 {
+		t.Errorf("unexpected cleanups: got %v, want %v", cleanups, 1)
+	}
+}
+
+func TestProvideOutDuplicateRejected(t *testing.T) {
+	dix.Reset()
+	errDuplicate := errors.New("duplicate")
+	defer dix.BeforeDuplicateRegister(func(ctx dix.BeforeDuplicateRegisterCtx) error {
+		return errDuplicate
+	})()
+
+	dix.AddProvider(dix.ProviderKey("replica"), func() (*Test, error) {
+		return NewTest("old replica"), nil
+	})
+
+	cleanups := 0
+	err := dix.Provide(dix.ProviderKey("main"), func() (testOut, func(), error) {
+		test := NewTest("main")
+		return testOut{Main: test, Replica: NewTest("replica"), IVal: test}, func() { cleanups++ }, nil
+	})
+	if !errors.Is(err, errDuplicate) {
+		t.Errorf("unexpected Provide() err: got %v, want %v", err, errDuplicate)
+	}
+
+	// fields before the rejected one are not added either
+	if dix.HasProvider[*Test](dix.ProviderKey("main")) {
+		t.Errorf("unexpected HasProvider(main): got %v, want %v", true, false)
+	}
+	if dix.HasProvider[ITestInterface](dix.ProviderKey("iface")) {
+		t.Errorf("unexpected HasProvider(iface): got %v, want %v", true, false)
+	}
+	replica, err := dix.GetProviderByKey[*Test](dix.ProviderKey("replica"))
+	if err != nil || replica.Name() != "old replica" {
+		t.Errorf("unexpected GetProviderByKey(replica): got %v, %v, want %v", replica, err, "old replica")
+	}
+
+	errs := dix.Reset()
+	if len(errs) != 0 {
+		t.Errorf("unexpected Reset() errs: got %v, want %v", errs, nil)
+	}
+	if cleanups != 0 {
+		t.Errorf("unexpected cleanups: got %v, want %v", cleanups, 0)
+	}
+}
+
+func TestProvideOutNoCache(t *testing.T) {
+	dix.Reset()
+
+	err := dix.Provide(dix.ProviderKey("main"), func() testOut {
+		return testOut{}
+	}, dix.WithProviderNoCache())
+	if !errors.Is(err, dix.ErrInvalidOption) {
+		t.Errorf("unexpected Provide() err: got %v, want %v", err, dix.ErrInvalidOption)
+	}
+	if dix.HasProvider[*Test](dix.ProviderKey("main")) {
+		t.Errorf("unexpected HasProvider(main): got %v, want %v", true, false)
+	}
+}
+
+func TestProvideReload(t *testing.T) {
+	dix.Reset()
+
+	runs := 0
+	cleanups := 0
+	err := dix.Provide(dix.ProviderKey("main"), func() (*Test, func()) {
+		runs++
+		return NewTest(fmt.Sprintf("run %d", runs)), func() { cleanups++ }
+	})
+	if err != nil {
+		t.Fatalf("unexpected Provide() err: got %v, want %v", err, nil)
+	}
+
+	first, err := dix.GetProviderByKey[*Test](dix.ProviderKey("main"))
+	if err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	reloaded, err := dix.GetProviderByKey[*Test](dix.ProviderKey("main"), dix.WithProviderReload())
+	if err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	if first.Name() != "run 1" || reloaded.Name() != "run 2" || runs != 2 {
+		t.Errorf("unexpected reload: got %v, %v, runs %v, want %v, %v, runs %v", first.Name(), reloaded.Name(), runs, "run 1", "run 2", 2)
+	}
+	// the cleanup of the replaced run
+	if cleanups != 1 {
+		t.Errorf("unexpected cleanups after reload: got %v, want %v", cleanups, 1)
+	}
+
+	cached, _ := dix.GetProviderByKey[*Test](dix.ProviderKey("main"))
+	if cached != reloaded {
+		t.Errorf("unexpected GetProviderByKey() after reload: got %v, want %v", cached.Name(), reloaded.Name())
+	}
+
+	dix.Reset()
+	if cleanups != 2 {
+		t.Errorf("unexpected cleanups after Reset(): got %v, want %v", cleanups, 2)
+	}
+}
+
+func TestProvideNoCacheCleanup(t *testing.T) {
+	dix.Reset()
+
+	err := dix.Provide(dix.ProviderKey("main"), func() (*Test, func()) {
+		return NewTest("main"), func() {}
+	}, dix.WithProviderNoCache())
+	if !errors.Is(err, dix.ErrInvalidOption) {
+		t.Errorf("unexpected Provide() err: got %v, want %v", err, dix.ErrInvalidOption)
+	}
+
+	// without a cleanup, every get runs it
+	runs := 0
+	err = dix.Provide(dix.ProviderKey("main"), func() *Test {
+		runs++
+		return NewTest("main")
+	}, dix.WithProviderNoCache())
+	if err != nil {
+		t.Fatalf("unexpected Provide() err: got %v, want %v", err, nil)
+	}
+	dix.GetProviderByKey[*Test](dix.ProviderKey("main"))
+	dix.GetProviderByKey[*Test](dix.ProviderKey("main"))
+	if runs != 2 {
+		t.Errorf("unexpected runs: got %v, want %v", runs, 2)
+	}
+}
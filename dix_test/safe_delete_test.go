@@ -0,0 +1,81 @@
+package dix_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jbterrylin/dix"
+)
+
+func TestDeleteByKeyWithCtxTimeout(t *testing.T) {
+	dix.SetSafeDelete(true)
+	defer dix.SetSafeDelete(false)
+
+	closed := false
+	err := dix.Add(TestKey, NewTest("test"), dix.WithValueOnClose(func() {
+		closed = true
+	}))
+	if err != nil {
+		t.Errorf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	_, err = dix.GetByKey[*Test](TestKey) // ref count + 1, never deducted
+	if err != nil {
+		t.Errorf("unexpected GetByKey() err: got %v, want %v", err, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = dix.DeleteByKeyWithCtx[*Test](ctx, TestKey)
+	if !errors.Is(err, dix.ErrRefCountTimeout) {
+		t.Errorf("unexpected DeleteByKeyWithCtx() err: got %v, want %v", err, dix.ErrRefCountTimeout)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected DeleteByKeyWithCtx() err: got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	var timeoutErr *dix.RefCountTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("unexpected DeleteByKeyWithCtx() err type: got %T, want %T", err, timeoutErr)
+	}
+	if len(timeoutErr.Leaks) != 1 || timeoutErr.Leaks[0].Key != TestKey || timeoutErr.Leaks[0].RefCount != 1 {
+		t.Errorf("unexpected Leaks: got %+v", timeoutErr.Leaks)
+	}
+
+	if closed {
+		t.Errorf("unexpected closed: got %v, want %v", closed, false)
+	}
+}
+
+func TestResetWithCtxForceClose(t *testing.T) {
+	dix.SetSafeDelete(true)
+	defer dix.SetSafeDelete(false)
+
+	closed := false
+	err := dix.Add(TestKey, NewTest("test"), dix.WithValueOnClose(func() {
+		closed = true
+	}))
+	if err != nil {
+		t.Errorf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	_, err = dix.GetByKey[*Test](TestKey) // ref count + 1, never deducted
+	if err != nil {
+		t.Errorf("unexpected GetByKey() err: got %v, want %v", err, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	errs := dix.ResetWithCtx(ctx, dix.WithResetForceClose())
+	if len(errs) != 1 || !errors.Is(errs[0], dix.ErrRefCountTimeout) {
+		t.Errorf("unexpected ResetWithCtx() errs: got %v, want %v", errs, dix.ErrRefCountTimeout)
+	}
+
+	if !closed {
+		t.Errorf("unexpected closed: got %v, want %v", closed, true)
+	}
+}
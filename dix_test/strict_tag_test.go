@@ -0,0 +1,122 @@
+package dix_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jbterrylin/dix"
+)
+
+type testValidStruct struct {
+	Store    *Test          `di:"key:test"`
+	IVal     ITestInterface `di:"key:test interface;optional:true"`
+	Provider *Test          `di:"type:provider;key:test;reload"`
+	Tests    []*Test        `di:"group"`
+	Skipped  int            `di:"-"`
+	TestEmbedded
+}
+
+func TestValidateStruct(t *testing.T) {
+	if err := dix.ValidateStruct[testValidStruct](); err != nil {
+		t.Errorf("unexpected ValidateStruct() err: got %v, want %v", err, nil)
+	}
+	if err := dix.ValidateStruct[*testValidStruct](); err != nil {
+		t.Errorf("unexpected ValidateStruct() err: got %v, want %v", err, nil)
+	}
+	if err := dix.ValidateStruct[int](); !errors.Is(err, dix.ErrInjectStructMustBePointerStruct) {
+		t.Errorf("unexpected ValidateStruct() err: got %v, want %v", err, dix.ErrInjectStructMustBePointerStruct)
+	}
+}
+
+type testInvalidTypeStruct struct {
+	Store *Test `di:"tpye:provider"`
+}
+
+type testInvalidFlagStruct struct {
+	Store *Test `di:"optinal"`
+}
+
+type testInvalidBoolStruct struct {
+	Store *Test `di:"optional:yes"`
+}
+
+type testValueReloadStruct struct {
+	Store *Test `di:"key:test;reload"`
+}
+
+type testGroupKeyStruct struct {
+	Tests []*Test `di:"group;key:test"`
+}
+
+type testNestedInvalidStruct struct {
+	Inner struct {
+		Store *Test `di:"key:test;key:test2"`
+	} `di:"inline"`
+}
+
+type testUnexportedStruct struct {
+	store *Test
+}
+
+func TestValidateStructInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func() error
+		field    string
+		token    string
+	}{
+		{"unknown option", dix.ValidateStruct[testInvalidTypeStruct], "Store", "tpye:provider"},
+		{"unknown flag", dix.ValidateStruct[testInvalidFlagStruct], "Store", "optinal"},
+		{"invalid bool", dix.ValidateStruct[testInvalidBoolStruct], "Store", "optional:yes"},
+		{"reload on value", dix.ValidateStruct[testValueReloadStruct], "Store", "reload"},
+		{"group with key", dix.ValidateStruct[testGroupKeyStruct], "Tests", "group"},
+		{"duplicate option", dix.ValidateStruct[testNestedInvalidStruct], "Inner.Store", "key:test2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.validate()
+			if !errors.Is(err, dix.ErrInvalidTag) {
+				t.Fatalf("unexpected ValidateStruct() err: got %v, want %v", err, dix.ErrInvalidTag)
+			}
+
+			var tagErr *dix.InvalidTagError
+			if !errors.As(err, &tagErr) {
+				t.Fatalf("unexpected ValidateStruct() err type: got %T, want %T", err, tagErr)
+			}
+			if tagErr.Field != tt.field {
+				t.Errorf("unexpected InvalidTagError.Field: got %v, want %v", tagErr.Field, tt.field)
+			}
+			if tagErr.Token != tt.token {
+				t.Errorf("unexpected InvalidTagError.Token: got %v, want %v", tagErr.Token, tt.token)
+			}
+		})
+	}
+
+	err := dix.ValidateStruct[testUnexportedStruct]()
+	if !errors.Is(err, dix.ErrFieldCannotBeSet) {
+		t.Errorf("unexpected ValidateStruct() err: got %v, want %v", err, dix.ErrFieldCannotBeSet)
+	}
+}
+
+func TestInjectStructStrictTag(t *testing.T) {
+	dix.Reset()
+	dix.Add(TestKey, NewTest("test"), dix.WithValueSetDefault())
+
+	var tmp testInvalidFlagStruct
+	if err := dix.InjectStruct(&tmp); err != nil {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, nil)
+	}
+
+	dix.SetStrictTag(true)
+	defer dix.SetStrictTag(false)
+
+	err := dix.InjectStruct(&tmp)
+	var tagErr *dix.InvalidTagError
+	if !errors.As(err, &tagErr) {
+		t.Fatalf("unexpected InjectStruct() err: got %v, want %v", err, dix.ErrInvalidTag)
+	}
+	if tagErr.Token != "optinal" {
+		t.Errorf("unexpected InvalidTagError.Token: got %v, want %v", tagErr.Token, "optinal")
+	}
+}
@@ -0,0 +1,131 @@
+package dix_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jbterrylin/dix"
+)
+
+func TestWatch(t *testing.T) {
+	dix.Reset()
+
+	if err := dix.Add("watch", &testWatch{id: 1}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+
+	ch := dix.Watch[*testWatch](context.Background(), "watch")
+	if v := receiveWatch(t, ch); v == nil || v.id != 1 {
+		t.Fatalf("unexpected Watch() current value: got %v, want id %v", v, 1)
+	}
+
+	// latest wins for a slow receiver, without blocking Add
+	for i := 2; i <= 4; i++ {
+		if err := dix.Add("watch", &testWatch{id: i}); err != nil {
+			t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+		}
+	}
+	if v := receiveWatch(t, ch); v == nil || v.id != 4 {
+		t.Fatalf("unexpected Watch() value: got %v, want id %v", v, 4)
+	}
+
+	// another key
+	if err := dix.Add("other", &testWatch{id: 5}); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	select {
+	case v := <-ch:
+		t.Fatalf("unexpected Watch() value of another key: got %v", v)
+	default:
+	}
+
+	if err := dix.DeleteByKey[*testWatch]("watch"); err != nil {
+		t.Fatalf("unexpected DeleteByKey() err: got %v, want %v", err, nil)
+	}
+	if v, ok := <-ch; ok {
+		t.Errorf("unexpected Watch() value after delete: got %v, want closed", v)
+	}
+}
+
+func TestWatchBeforeAdd(t *testing.T) {
+	dix.Reset()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := dix.Watch[*testWatch](ctx, dix.DefaultValueKey)
+
+	// the default key is set through another key
+	if err := dix.Add("watch", &testWatch{id: 1}, dix.WithValueSetDefault()); err != nil {
+		t.Fatalf("unexpected Add() err: got %v, want %v", err, nil)
+	}
+	if v := receiveWatch(t, ch); v == nil || v.id != 1 {
+		t.Fatalf("unexpected Watch() value: got %v, want id %v", v, 1)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Errorf("unexpected Watch() value after cancel: got a value, want closed")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("unexpected Watch() after cancel: not closed")
+	}
+}
+
+func TestWatchProvider(t *testing.T) {
+	dix.Reset()
+
+	id := 0
+	if err := dix.AddProvider("watch", func() (*testWatch, error) {
+		id++
+		return &testWatch{id: id}, nil
+	}); err != nil {
+		t.Fatalf("unexpected AddProvider() err: got %v, want %v", err, nil)
+	}
+
+	ch := dix.WatchProvider[*testWatch](context.Background(), "watch")
+	select {
+	case v := <-ch:
+		t.Fatalf("unexpected WatchProvider() value before run: got %v", v)
+	default:
+	}
+
+	if _, err := dix.GetProviderByKey[*testWatch]("watch"); err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	if v := receiveWatch(t, ch); v == nil || v.id != 1 {
+		t.Fatalf("unexpected WatchProvider() value: got %v, want id %v", v, 1)
+	}
+	if _, err := dix.GetProviderByKey[*testWatch]("watch", dix.WithProviderReload()); err != nil {
+		t.Fatalf("unexpected GetProviderByKey() err: got %v, want %v", err, nil)
+	}
+	if v := receiveWatch(t, ch); v == nil || v.id != 2 {
+		t.Fatalf("unexpected WatchProvider() value after reload: got %v, want id %v", v, 2)
+	}
+
+	// a new watcher receives the cached value
+	if v := receiveWatch(t, dix.WatchProvider[*testWatch](context.Background(), "watch")); v == nil || v.id != 2 {
+		t.Fatalf("unexpected WatchProvider() cached value: got %v, want id %v", v, 2)
+	}
+
+	dix.Reset()
+	if v, ok := <-ch; ok {
+		t.Errorf("unexpected WatchProvider() value after reset: got %v, want closed", v)
+	}
+}
+
+func receiveWatch(t *testing.T, ch <-chan *testWatch) *testWatch {
+	t.Helper()
+	select {
+	case v := <-ch:
+		return v
+	case <-time.After(time.Second):
+		t.Fatalf("unexpected watch: no value received")
+		return nil
+	}
+}
+
+type testWatch struct {
+	id int
+}
@@ -19,15 +19,3 @@ func (c ProviderKey) IsValid() bool {
 func (c ProviderKey) Value() string {
 	return string(c)
 }
-
-type injectTagFlag string
-
-func (c injectTagFlag) Value() string {
-	return string(c)
-}
-
-type injectTagFlagTypeOpt string
-
-func (c injectTagFlagTypeOpt) Value() string {
-	return string(c)
-}
@@ -0,0 +1,70 @@
+package dix
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RefCountLeak describes a value which still had outstanding references when waiting for safe delete stopped.
+type RefCountLeak struct {
+	Type     reflect.Type
+	Key      ValueKey
+	RefCount int64
+}
+
+// RefCountTimeoutError is returned when ctx ends before the ref counter of every value reaches zero.
+// errors.Is(err, ErrRefCountTimeout) reports true, Unwrap returns ctx's error.
+type RefCountTimeoutError struct {
+	Leaks []RefCountLeak
+	Err   error
+}
+
+func newRefCountTimeoutError(err error, leaks ...RefCountLeak) *RefCountTimeoutError {
+	return &RefCountTimeoutError{
+		Leaks: leaks,
+		Err:   err,
+	}
+}
+
+func (e *RefCountTimeoutError) Error() string {
+	leaks := make([]string, 0, len(e.Leaks))
+	for _, leak := range e.Leaks {
+		leaks = append(leaks, fmt.Sprintf("type=%v, key=%v, ref count=%d", leak.Type, leak.Key, leak.RefCount))
+	}
+	return fmt.Sprintf("%v: %v: [%v]", ErrRefCountTimeout, e.Err, strings.Join(leaks, "; "))
+}
+
+func (e *RefCountTimeoutError) Is(target error) bool {
+	return target == ErrRefCountTimeout
+}
+
+func (e *RefCountTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// InvalidTagError is returned for a malformed or contradictory `di` tag.
+// errors.Is(err, ErrInvalidTag) reports true.
+type InvalidTagError struct {
+	Struct reflect.Type
+	Field  string // field path from the injected target
+	Token  string
+	Reason string
+}
+
+func newInvalidTagError(structType reflect.Type, field string, token string, reason string) *InvalidTagError {
+	return &InvalidTagError{
+		Struct: structType,
+		Field:  field,
+		Token:  token,
+		Reason: reason,
+	}
+}
+
+func (e *InvalidTagError) Error() string {
+	return fmt.Sprintf("failed at struct=%v, field name=%v, token=%q: %v: %v", e.Struct, e.Field, e.Token, ErrInvalidTag, e.Reason)
+}
+
+func (e *InvalidTagError) Is(target error) bool {
+	return target == ErrInvalidTag
+}
@@ -0,0 +1,319 @@
+package dix
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// group holds every contribution of one type, sorted by priority then registration order.
+type group struct {
+	mu      sync.RWMutex
+	entries []*groupEntry
+}
+
+type groupEntry struct {
+	name string
+
+	// only one of them is set
+	value    *containerValue
+	provider *containerProvider
+}
+
+func (e *groupEntry) order() (priority int, seq uint64) {
+	if e.value != nil {
+		return e.value.order()
+	}
+	return e.provider.order()
+}
+
+type groupResult struct {
+	name  string
+	value any
+}
+
+func newGroup() *group {
+	return &group{
+		entries: make([]*groupEntry, 0),
+	}
+}
+
+// add appends entry, replacing the entry with the same non-empty name if any.
+func (g *group) add(entry *groupEntry) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if entry.name != "" {
+		for i, e := range g.entries {
+			if e.name == entry.name {
+				g.entries = append(g.entries[:i], g.entries[i+1:]...)
+				break
+			}
+		}
+	}
+
+	g.entries = append(g.entries, entry)
+	sort.SliceStable(g.entries, func(i, j int) bool {
+		return orderLess(g.entries[i], g.entries[j])
+	})
+}
+
+func (g *group) snapshot() []*groupEntry {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	entries := make([]*groupEntry, len(g.entries))
+	copy(entries, g.entries)
+	return entries
+}
+
+// Contribute adds val to the group of T. Unlike Add, no key is needed.
+func Contribute[T any](val T, opts ...ContributeOption) error {
+	// handle options
+	var opt contributeOption
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	onCloseHook := opt.onCloseHook
+	if onCloseHook == nil && !opt.skipAutoClose {
+		onCloseHook = closerOnCloseHook(val)
+	}
+
+	addToGroup(t, &groupEntry{
+		name:  opt.name,
+		value: newContainerValue(val, onCloseHook, opt.priority, opt.tagMap),
+	})
+	return nil
+}
+
+// ContributeProvider adds a factory function to the group of T.
+// It runs when the group is resolved, and its value is cached unless WithContributeNoCache is set.
+func ContributeProvider[T any](value func() (T, error), opts ...ContributeOption) error {
+	if value == nil {
+		return ErrValueIsNil
+	}
+	return contributeProvider(value, nil, opts...)
+}
+
+func ContributeCtxProvider[T any](valueWithCtx func(context.Context) (T, error), opts ...ContributeOption) error {
+	if valueWithCtx == nil {
+		return ErrValueIsNil
+	}
+	return contributeProvider(nil, valueWithCtx, opts...)
+}
+
+func contributeProvider[T any](value func() (T, error), valueWithCtx func(context.Context) (T, error), opts ...ContributeOption) error {
+	// handle options
+	var opt contributeOption
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	var tmp *containerProvider
+	if value != nil {
+		tmp = newContainerProvider(
+			func() (any, error) {
+				return value()
+			},
+			opt.noCache,
+			opt.priority,
+			opt.tagMap,
+		)
+	}
+	if valueWithCtx != nil {
+		tmp = newCtxContainerProvider(
+			func(ctx context.Context) (any, error) {
+				return valueWithCtx(ctx)
+			},
+			opt.noCache,
+			opt.priority,
+			opt.tagMap,
+		)
+	}
+
+	addToGroup(t, &groupEntry{
+		name:     opt.name,
+		provider: tmp,
+	})
+	return nil
+}
+
+func addToGroup(t reflect.Type, entry *groupEntry) {
+	g, _ := Container.typeGroupMap.GetOrSet(t, newGroup)
+	g.add(entry)
+}
+
+// GetSet returns every contribution of T, sorted by priority (higher first) then registration order.
+func GetSet[T any]() ([]T, error) {
+	return GetSetWithCtx[T](context.Background())
+}
+
+func GetSetWithCtx[T any](ctx context.Context) ([]T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	results, err := getGroupByType(ctx, t)
+	if err != nil {
+		return []T{}, err
+	}
+
+	values := make([]T, 0, len(results))
+	for _, result := range results {
+		values = append(values, result.value.(T))
+	}
+	return values, nil
+}
+
+// GetMap returns every contribution of T added with WithContributeName, keyed by name.
+func GetMap[T any]() (map[string]T, error) {
+	return GetMapWithCtx[T](context.Background())
+}
+
+func GetMapWithCtx[T any](ctx context.Context) (map[string]T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	results, err := getGroupByType(ctx, t)
+	if err != nil {
+		return map[string]T{}, err
+	}
+
+	values := make(map[string]T, len(results))
+	for _, result := range results {
+		if result.name == "" {
+			continue
+		}
+		values[result.name] = result.value.(T)
+	}
+	return values, nil
+}
+
+func getGroupByType(ctx context.Context, t reflect.Type) ([]groupResult, error) {
+	g, exist := Container.typeGroupMap.Get(t)
+	if !exist {
+		return []groupResult{}, nil
+	}
+
+	entries := g.snapshot()
+	results := make([]groupResult, 0, len(entries))
+	for _, entry := range entries {
+		if entry.value != nil {
+			entry.value.mu.Lock()
+			entry.value.setAccessed()
+			entry.value.mu.Unlock()
+
+			results = append(results, groupResult{name: entry.name, value: entry.value.value})
+			continue
+		}
+
+		val, err := runProvider(ctx, t, nil, entry.provider, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed at type=%v, name=%v: %w", t, entry.name, err)
+		}
+		results = append(results, groupResult{name: entry.name, value: val})
+	}
+	return results, nil
+}
+
+// getFromGroup resolves a []T or map[string]T from the group of T.
+func getFromGroup(ctx context.Context, typ reflect.Type) (*reflect.Value, error) {
+	switch {
+	case typ.Kind() == reflect.Slice:
+		results, err := getGroupByType(ctx, typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		tmp := reflect.MakeSlice(typ, 0, len(results))
+		for _, result := range results {
+			tmp = reflect.Append(tmp, groupResultValue(typ.Elem(), result))
+		}
+		return &tmp, nil
+	case typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String:
+		results, err := getGroupByType(ctx, typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		tmp := reflect.MakeMapWithSize(typ, len(results))
+		for _, result := range results {
+			if result.name == "" {
+				continue
+			}
+			tmp.SetMapIndex(reflect.ValueOf(result.name).Convert(typ.Key()), groupResultValue(typ.Elem(), result))
+		}
+		return &tmp, nil
+	default:
+		return nil, ErrTypeMismatch
+	}
+}
+
+func groupResultValue(elem reflect.Type, result groupResult) reflect.Value {
+	if result.value == nil {
+		return reflect.Zero(elem)
+	}
+	return reflect.ValueOf(result.value)
+}
+
+func resetGroups(ctx context.Context, opt resetOption) ([]error, []RefCountLeak) {
+	typeGroupMap := make(map[reflect.Type]*group, Container.typeGroupMap.Size())
+	Container.typeGroupMap.Range(func(typ reflect.Type, g *group) bool {
+		typeGroupMap[typ] = g
+		return true
+	})
+
+	var (
+		errs     = make([]error, 0)
+		leaks    = make([]RefCountLeak, 0)
+		errsLock sync.Mutex
+		wg       sync.WaitGroup
+	)
+
+	sem := make(chan struct{}, Container.resetMaxConcurrent)
+
+	for typ, g := range typeGroupMap {
+		// delete first so others won't see it
+		Container.typeGroupMap.Del(typ)
+
+		if opt.skipOnClose {
+			continue
+		}
+
+		for _, entry := range g.snapshot() {
+			if entry.value == nil {
+				continue
+			}
+
+			wg.Add(1)
+			go func(typ reflect.Type, entry *groupEntry) {
+				defer wg.Done()
+
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				entry.value.lock()
+				closeFunc := entry.value.closer()
+				entry.value.unlock()
+				if closeFunc == nil {
+					return
+				}
+
+				refErr, closeErr := closeFunc(ctx, opt.forceClose)
+				errsLock.Lock()
+				defer errsLock.Unlock()
+				if refErr != nil {
+					leaks = append(leaks, RefCountLeak{Type: typ, Key: ValueKey(entry.name), RefCount: entry.value.refCount()})
+				}
+				if closeErr != nil {
+					errs = append(errs, fmt.Errorf("failed at type=%v, name=%v: %w", typ, entry.name, closeErr))
+				}
+			}(typ, entry)
+		}
+	}
+
+	wg.Wait()
+	return errs, leaks
+}
@@ -1,67 +1,215 @@
 package dix
 
-import "reflect"
+import (
+	"reflect"
+	"time"
+)
 
 type (
 	AfterAddCtx struct {
-		Type              reflect.Type
-		ValueKey          *ValueKey
-		ContainerValue    *containerValue
-		ProviderKey       *ProviderKey
+		Type     reflect.Type
+		ValueKey *ValueKey
+		// Deprecated: use ValueInfo.
+		ContainerValue *containerValue
+		ValueInfo      *ValueInfo
+		ProviderKey    *ProviderKey
+		// Deprecated: use ProviderInfo.
 		ContainerProvider *containerProvider
+		ProviderInfo      *ProviderInfo
 	}
 
 	AfterAddFunc func(ctx AfterAddCtx)
 
 	AfterProviderRunCtx struct {
-		Type              reflect.Type
-		Key               ProviderKey
+		Type reflect.Type
+		Key  ProviderKey
+		// Deprecated: use ProviderInfo.
 		ContainerProvider *containerProvider
+		ProviderInfo      ProviderInfo
 		Value             any
 	}
 
 	AfterProviderRunFunc func(ctx AfterProviderRunCtx)
 
 	AfterFirstAccessCtx struct {
-		Type              reflect.Type
-		ValueKey          *ValueKey
-		ContainerValue    *containerValue
-		ProviderKey       *ProviderKey
+		Type     reflect.Type
+		ValueKey *ValueKey
+		// Deprecated: use ValueInfo.
+		ContainerValue *containerValue
+		ValueInfo      *ValueInfo
+		ProviderKey    *ProviderKey
+		// Deprecated: use ProviderInfo.
 		ContainerProvider *containerProvider
+		ProviderInfo      *ProviderInfo
 	}
 
 	AfterFirstAccessFunc func(ctx AfterFirstAccessCtx)
 
 	BeforeDuplicateRegisterCtx struct {
-		Type                 reflect.Type
-		ValueKey             *ValueKey
-		OldContainerValue    *containerValue
-		NewContainerValue    *containerValue
-		ProviderKey          *ProviderKey
+		Type     reflect.Type
+		ValueKey *ValueKey
+		// Deprecated: use OldValueInfo.
+		OldContainerValue *containerValue
+		// Deprecated: use NewValueInfo.
+		NewContainerValue *containerValue
+		OldValueInfo      *ValueInfo
+		NewValueInfo      *ValueInfo
+		ProviderKey       *ProviderKey
+		// Deprecated: use OldProviderInfo.
 		OldContainerProvider *containerProvider
+		// Deprecated: use NewProviderInfo.
 		NewContainerProvider *containerProvider
+		OldProviderInfo      *ProviderInfo
+		NewProviderInfo      *ProviderInfo
 		IsDefault            bool
 	}
 
 	BeforeDuplicateRegisterFunc func(ctx BeforeDuplicateRegisterCtx) error
+
+	AfterConfigReloadCtx struct {
+		Type reflect.Type
+		Key  ValueKey
+		Old  any
+		New  any
+	}
+
+	AfterConfigReloadFunc func(ctx AfterConfigReloadCtx)
+
+	BeforeProviderRunCtx struct {
+		Type   reflect.Type
+		Key    ProviderKey
+		Tags   map[string]any
+		Reload bool
+	}
+
+	BeforeProviderRunFunc func(ctx BeforeProviderRunCtx)
+
+	ProviderRunFailedCtx struct {
+		Type     reflect.Type
+		Key      ProviderKey
+		Tags     map[string]any
+		Duration time.Duration
+		Err      error
+	}
+
+	ProviderRunFailedFunc func(ctx ProviderRunFailedCtx)
+
+	ProviderCacheHitCtx struct {
+		Type reflect.Type
+		Key  ProviderKey
+		Tags map[string]any
+	}
+
+	ProviderCacheHitFunc func(ctx ProviderCacheHitCtx)
+
+	AfterDeleteCtx struct {
+		Type        reflect.Type
+		ValueKey    *ValueKey
+		ProviderKey *ProviderKey
+		Tags        map[string]any
+		Err         error
+	}
+
+	AfterDeleteFunc func(ctx AfterDeleteCtx)
+
+	BeforeCloseCtx struct {
+		Type        reflect.Type
+		ValueKey    *ValueKey
+		ProviderKey *ProviderKey
+		Tags        map[string]any
+	}
+
+	BeforeCloseFunc func(ctx BeforeCloseCtx)
+
+	AfterCloseCtx struct {
+		Type        reflect.Type
+		ValueKey    *ValueKey
+		ProviderKey *ProviderKey
+		Tags        map[string]any
+		Duration    time.Duration
+		Err         error
+	}
+
+	AfterCloseFunc func(ctx AfterCloseCtx)
+
+	BeforeResetCtx struct {
+		SkipOnClose bool
+		ForceClose  bool
+	}
+
+	BeforeResetFunc func(ctx BeforeResetCtx)
+
+	AfterResetCtx struct {
+		Duration time.Duration
+		Errs     []error
+	}
+
+	AfterResetFunc func(ctx AfterResetCtx)
+
+	AfterReplaceCtx struct {
+		Type reflect.Type
+		Key  ValueKey
+		Old  any
+		New  any
+	}
+
+	AfterReplaceFunc func(ctx AfterReplaceCtx)
+
+	RedactCtx struct {
+		Type        reflect.Type
+		ValueKey    *ValueKey
+		ProviderKey *ProviderKey
+		Tags        map[string]any
+		Value       any
+	}
+
+	// RedactFunc returns true to hide the value from Describe.
+	RedactFunc func(ctx RedactCtx) bool
 )
 
+// newInfos returns the snapshots of the hook ctx of a value or provider, nil if absent.
+func newInfos(
+	typ reflect.Type,
+	valueKey *ValueKey, containerValue *containerValue,
+	providerKey *ProviderKey, containerProvider *containerProvider,
+) (*ValueInfo, *ProviderInfo) {
+	var valueInfo *ValueInfo
+	if valueKey != nil && containerValue != nil {
+		tmp := containerValue.info(typ, *valueKey)
+		valueInfo = &tmp
+	}
+	var providerInfo *ProviderInfo
+	if providerKey != nil && containerProvider != nil {
+		tmp := containerProvider.info(typ, *providerKey)
+		providerInfo = &tmp
+	}
+	return valueInfo, providerInfo
+}
+
 func NewAfterAddCtx(
 	typ reflect.Type,
 	valueKey *ValueKey, containerValue *containerValue,
 	providerKey *ProviderKey, containerProvider *containerProvider,
 ) AfterAddCtx {
+	valueInfo, providerInfo := newInfos(typ, valueKey, containerValue, providerKey, containerProvider)
 	return AfterAddCtx{
 		Type:              typ,
 		ValueKey:          valueKey,
 		ContainerValue:    containerValue,
+		ValueInfo:         valueInfo,
 		ProviderKey:       providerKey,
 		ContainerProvider: containerProvider,
+		ProviderInfo:      providerInfo,
 	}
 }
 
-func AfterAdd(f AfterAddFunc) {
-	Container.afterAdd = f
+// AfterAdd subscribes f to successful additions of values and providers.
+func AfterAdd(f AfterAddFunc) Unsubscribe {
+	if f == nil {
+		Container.afterAdd.clear()
+		return func() {}
+	}
+	return Container.afterAdd.add(f)
 }
 
 func NewAfterProviderRunCtx(
@@ -73,12 +221,18 @@ func NewAfterProviderRunCtx(
 		Type:              typ,
 		Key:               key,
 		ContainerProvider: containerProvider,
+		ProviderInfo:      containerProvider.info(typ, key),
 		Value:             value,
 	}
 }
 
-func AfterProviderRun(f AfterProviderRunFunc) {
-	Container.afterProviderRun = f
+// AfterProviderRun subscribes f to successful runs of provider factories.
+func AfterProviderRun(f AfterProviderRunFunc) Unsubscribe {
+	if f == nil {
+		Container.afterProviderRun.clear()
+		return func() {}
+	}
+	return Container.afterProviderRun.add(f)
 }
 
 func NewAfterFirstAccessCtx(
@@ -86,17 +240,25 @@ func NewAfterFirstAccessCtx(
 	valueKey *ValueKey, containerValue *containerValue,
 	providerKey *ProviderKey, containerProvider *containerProvider,
 ) AfterFirstAccessCtx {
+	valueInfo, providerInfo := newInfos(typ, valueKey, containerValue, providerKey, containerProvider)
 	return AfterFirstAccessCtx{
 		Type:              typ,
 		ValueKey:          valueKey,
 		ContainerValue:    containerValue,
+		ValueInfo:         valueInfo,
 		ProviderKey:       providerKey,
 		ContainerProvider: containerProvider,
+		ProviderInfo:      providerInfo,
 	}
 }
 
-func AfterFirstAccess(f AfterFirstAccessFunc) {
-	Container.afterFirstAccess = f
+// AfterFirstAccess subscribes f to the first access of each value and provider.
+func AfterFirstAccess(f AfterFirstAccessFunc) Unsubscribe {
+	if f == nil {
+		Container.afterFirstAccess.clear()
+		return func() {}
+	}
+	return Container.afterFirstAccess.add(f)
 }
 
 func NewBeforeDuplicateRegisterCtx(
@@ -105,18 +267,286 @@ func NewBeforeDuplicateRegisterCtx(
 	providerKey *ProviderKey, oldContainerProvider *containerProvider, newContainerProvider *containerProvider,
 	isDefault bool,
 ) BeforeDuplicateRegisterCtx {
+	// the old one is registered under the default key if isDefault
+	var oldValueKey *ValueKey
+	if valueKey != nil {
+		oldValueKey = valueKey
+		if isDefault {
+			tmp := DefaultValueKey
+			oldValueKey = &tmp
+		}
+	}
+	var oldProviderKey *ProviderKey
+	if providerKey != nil {
+		oldProviderKey = providerKey
+		if isDefault {
+			tmp := DefaultProviderKey
+			oldProviderKey = &tmp
+		}
+	}
+	oldValueInfo, oldProviderInfo := newInfos(typ, oldValueKey, oldContainerValue, oldProviderKey, oldContainerProvider)
+	newValueInfo, newProviderInfo := newInfos(typ, valueKey, newContainerValue, providerKey, newContainerProvider)
+
 	return BeforeDuplicateRegisterCtx{
 		Type:                 typ,
 		ValueKey:             valueKey,
 		OldContainerValue:    oldContainerValue,
 		NewContainerValue:    newContainerValue,
+		OldValueInfo:         oldValueInfo,
+		NewValueInfo:         newValueInfo,
 		ProviderKey:          providerKey,
 		OldContainerProvider: oldContainerProvider,
 		NewContainerProvider: newContainerProvider,
+		OldProviderInfo:      oldProviderInfo,
+		NewProviderInfo:      newProviderInfo,
 		IsDefault:            isDefault,
 	}
 }
 
-func BeforeDuplicateRegister(f BeforeDuplicateRegisterFunc) {
-	Container.beforeDuplicateRegister = f
+// BeforeDuplicateRegister subscribes f to overwrites of a type and key.
+// The first subscriber returning an error aborts the registration, and the next ones are not called.
+func BeforeDuplicateRegister(f BeforeDuplicateRegisterFunc) Unsubscribe {
+	if f == nil {
+		Container.beforeDuplicateRegister.clear()
+		return func() {}
+	}
+	return Container.beforeDuplicateRegister.add(f)
+}
+
+func NewAfterConfigReloadCtx(
+	typ reflect.Type,
+	key ValueKey,
+	old any, new any,
+) AfterConfigReloadCtx {
+	return AfterConfigReloadCtx{
+		Type: typ,
+		Key:  key,
+		Old:  old,
+		New:  new,
+	}
+}
+
+// AfterConfigReload subscribes f to successful reloads of configs.
+func AfterConfigReload(f AfterConfigReloadFunc) Unsubscribe {
+	if f == nil {
+		Container.afterConfigReload.clear()
+		return func() {}
+	}
+	return Container.afterConfigReload.add(f)
+}
+
+func NewBeforeProviderRunCtx(
+	typ reflect.Type,
+	key ProviderKey, tags map[string]any,
+	reload bool,
+) BeforeProviderRunCtx {
+	return BeforeProviderRunCtx{
+		Type:   typ,
+		Key:    key,
+		Tags:   tags,
+		Reload: reload,
+	}
+}
+
+// BeforeProviderRun subscribes f to the start of provider factory runs, including reloads.
+func BeforeProviderRun(f BeforeProviderRunFunc) Unsubscribe {
+	if f == nil {
+		Container.beforeProviderRun.clear()
+		return func() {}
+	}
+	return Container.beforeProviderRun.add(f)
+}
+
+func NewProviderRunFailedCtx(
+	typ reflect.Type,
+	key ProviderKey, tags map[string]any,
+	duration time.Duration, err error,
+) ProviderRunFailedCtx {
+	return ProviderRunFailedCtx{
+		Type:     typ,
+		Key:      key,
+		Tags:     tags,
+		Duration: duration,
+		Err:      err,
+	}
+}
+
+// ProviderRunFailed subscribes f to provider factory runs returning an error, failing PostInject, or outliving their ctx.
+func ProviderRunFailed(f ProviderRunFailedFunc) Unsubscribe {
+	if f == nil {
+		Container.providerRunFailed.clear()
+		return func() {}
+	}
+	return Container.providerRunFailed.add(f)
+}
+
+func NewProviderCacheHitCtx(
+	typ reflect.Type,
+	key ProviderKey, tags map[string]any,
+) ProviderCacheHitCtx {
+	return ProviderCacheHitCtx{
+		Type: typ,
+		Key:  key,
+		Tags: tags,
+	}
+}
+
+// ProviderCacheHit subscribes f to provider gets served from the cached value, without running the factory.
+func ProviderCacheHit(f ProviderCacheHitFunc) Unsubscribe {
+	if f == nil {
+		Container.providerCacheHit.clear()
+		return func() {}
+	}
+	return Container.providerCacheHit.add(f)
+}
+
+func NewAfterDeleteCtx(
+	typ reflect.Type,
+	valueKey *ValueKey, providerKey *ProviderKey,
+	tags map[string]any, err error,
+) AfterDeleteCtx {
+	return AfterDeleteCtx{
+		Type:        typ,
+		ValueKey:    valueKey,
+		ProviderKey: providerKey,
+		Tags:        tags,
+		Err:         err,
+	}
+}
+
+// AfterDelete subscribes f to deletions of values and providers. Err is the error returned by the delete, if any.
+func AfterDelete(f AfterDeleteFunc) Unsubscribe {
+	if f == nil {
+		Container.afterDelete.clear()
+		return func() {}
+	}
+	return Container.afterDelete.add(f)
+}
+
+func NewBeforeCloseCtx(
+	typ reflect.Type,
+	valueKey *ValueKey, providerKey *ProviderKey,
+	tags map[string]any,
+) BeforeCloseCtx {
+	return BeforeCloseCtx{
+		Type:        typ,
+		ValueKey:    valueKey,
+		ProviderKey: providerKey,
+		Tags:        tags,
+	}
+}
+
+// BeforeClose subscribes f to the close of values and providers having an OnCloseHook or a PreClose method,
+// on delete and reset.
+func BeforeClose(f BeforeCloseFunc) Unsubscribe {
+	if f == nil {
+		Container.beforeClose.clear()
+		return func() {}
+	}
+	return Container.beforeClose.add(f)
+}
+
+func NewAfterCloseCtx(
+	typ reflect.Type,
+	valueKey *ValueKey, providerKey *ProviderKey,
+	tags map[string]any,
+	duration time.Duration, err error,
+) AfterCloseCtx {
+	return AfterCloseCtx{
+		Type:        typ,
+		ValueKey:    valueKey,
+		ProviderKey: providerKey,
+		Tags:        tags,
+		Duration:    duration,
+		Err:         err,
+	}
+}
+
+// AfterClose subscribes f to the end of each close started with BeforeClose.
+// Duration includes waiting for the ref counter when safe delete is enabled.
+func AfterClose(f AfterCloseFunc) Unsubscribe {
+	if f == nil {
+		Container.afterClose.clear()
+		return func() {}
+	}
+	return Container.afterClose.add(f)
+}
+
+func NewBeforeResetCtx(skipOnClose bool, forceClose bool) BeforeResetCtx {
+	return BeforeResetCtx{
+		SkipOnClose: skipOnClose,
+		ForceClose:  forceClose,
+	}
+}
+
+// BeforeReset subscribes f to the start of Reset.
+func BeforeReset(f BeforeResetFunc) Unsubscribe {
+	if f == nil {
+		Container.beforeReset.clear()
+		return func() {}
+	}
+	return Container.beforeReset.add(f)
+}
+
+func NewAfterResetCtx(duration time.Duration, errs []error) AfterResetCtx {
+	return AfterResetCtx{
+		Duration: duration,
+		Errs:     errs,
+	}
+}
+
+// AfterReset subscribes f to the end of Reset, with the errors it returns.
+func AfterReset(f AfterResetFunc) Unsubscribe {
+	if f == nil {
+		Container.afterReset.clear()
+		return func() {}
+	}
+	return Container.afterReset.add(f)
+}
+
+func NewAfterReplaceCtx(
+	typ reflect.Type,
+	key ValueKey,
+	old any, new any,
+) AfterReplaceCtx {
+	return AfterReplaceCtx{
+		Type: typ,
+		Key:  key,
+		Old:  old,
+		New:  new,
+	}
+}
+
+// AfterReplace subscribes f to values replaced by Add, once per replaced key.
+func AfterReplace(f AfterReplaceFunc) Unsubscribe {
+	if f == nil {
+		Container.afterReplace.clear()
+		return func() {}
+	}
+	return Container.afterReplace.add(f)
+}
+
+func NewRedactCtx(
+	typ reflect.Type,
+	valueKey *ValueKey, providerKey *ProviderKey,
+	tags map[string]any,
+	value any,
+) RedactCtx {
+	return RedactCtx{
+		Type:        typ,
+		ValueKey:    valueKey,
+		ProviderKey: providerKey,
+		Tags:        tags,
+		Value:       value,
+	}
+}
+
+// Redact subscribes f to the values Describe renders. A value is redacted if any subscriber returns true or panics.
+// Values tagged with SecretTag are redacted without asking.
+func Redact(f RedactFunc) Unsubscribe {
+	if f == nil {
+		Container.redact.clear()
+		return func() {}
+	}
+	return Container.redact.add(f)
 }
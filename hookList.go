@@ -0,0 +1,132 @@
+package dix
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Unsubscribe removes a hook subscriber. Calling it more than once is a no-op.
+//
+// A hook may have any number of subscribers, called in registration order. Subscribing nil removes them all, except those of Watch, Await and the typed hooks such as OnAdd.
+// A panicking subscriber is recovered and passed to the handler set by SetHookPanicHandler, then the next one is called.
+// A panicking BeforeDuplicateRegister subscriber rejects the registration with an error wrapping ErrHookPanic instead.
+type Unsubscribe func()
+
+// hookList is the subscribers of a hook, called in registration order.
+type hookList[F any] struct {
+	name string
+
+	mu     sync.Mutex
+	nextID uint64
+	// copy on write, so run never holds the lock while calling subscribers
+	entries []hookEntry[F]
+}
+
+type hookEntry[F any] struct {
+	id uint64
+	f  F
+	// subscribed by dix itself, e.g. for Watch or OnAdd, so clear keeps it
+	internal bool
+}
+
+func newHookList[F any](name string) *hookList[F] {
+	return &hookList[F]{name: name}
+}
+
+func (l *hookList[F]) add(f F) Unsubscribe {
+	return l.addEntry(f, false)
+}
+
+// addInternal subscribes f like add, but clearing the hook keeps it.
+func (l *hookList[F]) addInternal(f F) Unsubscribe {
+	return l.addEntry(f, true)
+}
+
+func (l *hookList[F]) addEntry(f F, internal bool) Unsubscribe {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.nextID++
+	id := l.nextID
+	entries := make([]hookEntry[F], len(l.entries), len(l.entries)+1)
+	copy(entries, l.entries)
+	l.entries = append(entries, hookEntry[F]{id: id, f: f, internal: internal})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { l.remove(id) })
+	}
+}
+
+func (l *hookList[F]) remove(id uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := make([]hookEntry[F], 0, len(l.entries))
+	for _, entry := range l.entries {
+		if entry.id != id {
+			entries = append(entries, entry)
+		}
+	}
+	l.entries = entries
+}
+
+// clear removes every subscriber added by add.
+func (l *hookList[F]) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var entries []hookEntry[F]
+	for _, entry := range l.entries {
+		if entry.internal {
+			entries = append(entries, entry)
+		}
+	}
+	l.entries = entries
+}
+
+func (l *hookList[F]) list() []hookEntry[F] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.entries
+}
+
+// has reports whether there is any subscriber, to skip building the hook ctx on hot paths.
+func (l *hookList[F]) has() bool {
+	return len(l.list()) > 0
+}
+
+// run calls every subscriber. A panicking subscriber is recovered, reported to the hook panic handler,
+// and does not stop the others.
+func (l *hookList[F]) run(call func(f F)) {
+	for _, entry := range l.list() {
+		_ = l.safeCall(func() error {
+			call(entry.f)
+			return nil
+		})
+	}
+}
+
+// runUntilErr calls the subscribers until one returns an error or panics, so a panic aborts the operation
+// rather than letting it through unchecked.
+func (l *hookList[F]) runUntilErr(call func(f F) error) error {
+	for _, entry := range l.list() {
+		if err := l.safeCall(func() error { return call(entry.f) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// safeCall returns an error wrapping ErrHookPanic if call panics.
+func (l *hookList[F]) safeCall(call func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed at hook=%v: %w: %v", l.name, ErrHookPanic, r)
+			if handler := Container.hookPanicHandler; handler != nil {
+				handler(l.name, r)
+			}
+		}
+	}()
+	return call()
+}
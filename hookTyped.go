@@ -0,0 +1,53 @@
+package dix
+
+import (
+	"reflect"
+)
+
+// OnAdd subscribes f to values of T added by Add.
+func OnAdd[T any](f func(key ValueKey, v T)) Unsubscribe {
+	if f == nil {
+		return func() {}
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	return Container.afterAdd.addInternal(func(ctx AfterAddCtx) {
+		if ctx.Type != t || ctx.ValueKey == nil {
+			return
+		}
+		f(*ctx.ValueKey, typed[T](ctx.ContainerValue.value))
+	})
+}
+
+// OnProviderRun subscribes f to successful runs of the provider factories of T, including reloads.
+func OnProviderRun[T any](f func(key ProviderKey, v T)) Unsubscribe {
+	if f == nil {
+		return func() {}
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	return Container.afterProviderRun.addInternal(func(ctx AfterProviderRunCtx) {
+		if ctx.Type != t {
+			return
+		}
+		f(ctx.Key, typed[T](ctx.Value))
+	})
+}
+
+// OnReplace subscribes f to values of T replaced by Add, including config reloads.
+func OnReplace[T any](f func(old, new T)) Unsubscribe {
+	if f == nil {
+		return func() {}
+	}
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	return Container.afterReplace.addInternal(func(ctx AfterReplaceCtx) {
+		if ctx.Type != t {
+			return
+		}
+		f(typed[T](ctx.Old), typed[T](ctx.New))
+	})
+}
+
+// typed returns v as T, the zero T if v is a nil interface.
+func typed[T any](v any) T {
+	tmp, _ := v.(T)
+	return tmp
+}
@@ -0,0 +1,92 @@
+package dix
+
+import (
+	"reflect"
+	"time"
+)
+
+// ValueInfo is a read-only snapshot of a registered value.
+type ValueInfo struct {
+	Type         reflect.Type
+	Key          ValueKey
+	Priority     int
+	CreatedAt    time.Time
+	AccessedAt   time.Time
+	IsAccessed   bool
+	RefCount     int64
+	Tags         map[string]any
+	HasCloseHook bool // an OnCloseHook or a PreClose method
+}
+
+// ProviderInfo is a read-only snapshot of a registered provider.
+type ProviderInfo struct {
+	Type           reflect.Type
+	Key            ProviderKey
+	Priority       int
+	CreatedAt      time.Time
+	AccessedAt     time.Time
+	IsAccessed     bool
+	Tags           map[string]any
+	NoCache        bool
+	HasCachedValue bool
+	HasCloseHook   bool // an OnCloseHook, or a cached value with a PreClose method
+}
+
+// info returns the snapshot of c registered under typ and key. The caller holds c.mu.
+func (c *containerValue) info(typ reflect.Type, key ValueKey) ValueInfo {
+	return ValueInfo{
+		Type:         typ,
+		Key:          key,
+		Priority:     c.priority,
+		CreatedAt:    c.createdAt,
+		AccessedAt:   c.accessedAt,
+		IsAccessed:   c.isAccessed,
+		RefCount:     c.refCount(),
+		Tags:         copyMap(c.tagMap),
+		HasCloseHook: c.needClose(),
+	}
+}
+
+// info returns the snapshot of c registered under typ and key. The caller holds c.mu.
+func (c *containerProvider) info(typ reflect.Type, key ProviderKey) ProviderInfo {
+	_, preCloser := c.cacheValue.(PreCloser)
+	return ProviderInfo{
+		Type:           typ,
+		Key:            key,
+		Priority:       c.priority,
+		CreatedAt:      c.createdAt,
+		AccessedAt:     c.accessedAt,
+		IsAccessed:     c.isAccessed,
+		Tags:           copyMap(c.tagMap),
+		NoCache:        c.noCache,
+		HasCachedValue: c.cacheValue != nil,
+		HasCloseHook:   c.onCloseHook != nil || preCloser,
+	}
+}
+
+// InspectValue returns a snapshot of the value of T under key.
+// Unlike GetByKey, it does not mark the value accessed, trigger hooks or touch the ref counter.
+func InspectValue[T any](key ValueKey) (ValueInfo, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	val, err := getContainerNestedMapValue(Container.typeKeyValueMap, t, key)
+	if err != nil {
+		return ValueInfo{}, err
+	}
+
+	val.mu.RLock()
+	defer val.mu.RUnlock()
+	return val.info(t, key), nil
+}
+
+// InspectProvider returns a snapshot of the provider of T under key, without running it.
+func InspectProvider[T any](key ProviderKey) (ProviderInfo, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	provider, err := getContainerNestedMapValue(Container.typeKeyProviderMap, t, key)
+	if err != nil {
+		return ProviderInfo{}, err
+	}
+
+	provider.mu.RLock()
+	defer provider.mu.RUnlock()
+	return provider.info(t, key), nil
+}
@@ -8,41 +8,73 @@ import (
 	"strconv"
 )
 
-// If the function returns two or more values and the last one is an error, it will be used as the returned error.
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// If the last value returned by the function is an error, it will be used as the returned error.
 func InjectFunc(fn any, opts ...InjectFuncOption) error {
 	return InjectFuncWithCtx(context.Background(), fn, opts...)
 }
 
-// If the function returns two or more values and the last one is an error, it will be used as the returned error.
+// If the last value returned by the function is an error, it will be used as the returned error.
+// If the first param is a context.Context, it receives ctx.
 func InjectFuncWithCtx(ctx context.Context, fn any, opts ...InjectFuncOption) error {
-	v := reflect.ValueOf(fn)
-	t := v.Type()
+	_, err := callInjectFunc(ctx, fn, opts...)
+	return err
+}
+
+// Invoke calls fn like InjectFuncWithCtx and returns its first value as R.
+// If the last value returned by fn is an error, it will be used as the returned error.
+func Invoke[R any](ctx context.Context, fn any, opts ...InjectFuncOption) (R, error) {
+	var zero R
 
-	if t.Kind() != reflect.Func {
-		return ErrInjectFuncMustBeFunc
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return zero, ErrInjectFuncMustBeFunc
 	}
 
-	variableNameIndexMap := make(map[string]int, t.NumIn())
-	for i := 0; i < t.NumIn(); i++ {
-		paramType := t.In(i)
-		variableNameIndexMap[paramType.Name()] = i
+	r := reflect.TypeOf((*R)(nil)).Elem()
+	if t.NumOut() == 0 || !t.Out(0).AssignableTo(r) || (t.NumOut() == 1 && t.Out(0) == errorType) {
+		return zero, fmt.Errorf("failed at result type=%v: %w", r, ErrTypeMismatch)
 	}
 
-	optMap := make(map[int]*injectFuncOption)
+	out, err := callInjectFunc(ctx, fn, opts...)
+	if err != nil {
+		return zero, err
+	}
+
+	tmp := reflect.New(r).Elem()
+	tmp.Set(out[0])
+	return tmp.Interface().(R), nil
+}
+
+// callInjectFunc calls fn with injected params. The trailing error, if any, is returned as err instead of in out.
+func callInjectFunc(ctx context.Context, fn any, opts ...InjectFuncOption) (out []reflect.Value, err error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return nil, ErrInjectFuncMustBeFunc
+	}
+	t := v.Type()
+	plan := getFuncPlan(t)
+
+	var optMap map[int]*injectFuncOption
+	if len(opts) > 0 {
+		optMap = make(map[int]*injectFuncOption, len(opts))
+	}
 	for _, o := range opts {
 		tmp := &injectFuncOption{}
 		o(tmp)
 
 		index := -1
 		if i, err := strconv.Atoi(tmp.variable); err == nil {
-			if i > t.NumIn()-1 || i < 0 {
-				return ErrInvalidVariable
+			if i > len(plan.in)-1 || i < 0 {
+				return nil, ErrInvalidVariable
 			}
 			index = i
-		} else if idx, ok := variableNameIndexMap[tmp.variable]; ok {
+		} else if idx, ok := plan.variableNameIndexMap[tmp.variable]; ok {
 			index = idx
 		} else {
-			return ErrInvalidVariable
+			return nil, ErrInvalidVariable
 		}
 
 		if _, exist := optMap[int(index)]; !exist {
@@ -52,50 +84,106 @@ func InjectFuncWithCtx(ctx context.Context, fn any, opts ...InjectFuncOption) er
 		}
 	}
 
-	in := make([]reflect.Value, t.NumIn())
-	for i := 0; i < t.NumIn(); i++ {
-		paramType := t.In(i)
-		var tag injectTag
-		if opt, exist := optMap[i]; exist {
-			tag = newInjectTag(opt.valType, opt.key, opt.reload, opt.optional)
+	in := make([]reflect.Value, len(plan.in))
+	for i, paramType := range plan.in {
+		if i == 0 && plan.hasCtx {
+			in[i] = reflect.ValueOf(&ctx).Elem()
+			continue
 		}
 
-		var err error
-
-		var tmp *reflect.Value
-		switch tag.valType {
-		case injectTagFlagTypeOptProvider.Value():
-			tmp, err = getFromProvider(ctx, paramType, tag)
-		default:
-			tmp, err = getFromValue(paramType, tag)
+		var tag injectTag
+		if opt, exist := optMap[i]; exist {
+			tag = applyInjectFuncOpt(tag, opt)
+		} else if plan.inStructs[i] {
+			tmp := reflect.New(paramType).Elem()
+			if err := injectStructValue(ctx, tmp, "", newInjectStructOption()); err != nil {
+				return nil, fmt.Errorf("failed at param type name=%v, param type=%v: %w", paramType.Name(), paramType.String(), err)
+			}
+			in[i] = tmp
+			continue
 		}
 
+		tmp, err := resolveInjectTag(ctx, paramType, tag)
 		if err != nil {
 			if errors.Is(err, ErrValueNotFound) && tag.optional {
+				in[i] = reflect.Zero(paramType)
 				continue
 			}
-			return fmt.Errorf("failed at param type name=%v, param type=%v: %w", paramType.Name(), paramType.String(), err)
+			return nil, fmt.Errorf("failed at param type name=%v, param type=%v: %w", paramType.Name(), paramType.String(), err)
 		}
 
 		in[i] = *tmp
 	}
 
-	v.Call(in)
+	out = v.Call(in)
 
-	return nil
+	if plan.hasErr {
+		last := out[len(out)-1]
+		out = out[:len(out)-1]
+		if !last.IsNil() {
+			return out, last.Interface().(error)
+		}
+	}
+
+	return out, nil
 }
 
 func mergeInjectFuncOpt(dst, src *injectFuncOption) {
 	if src.valType != "" {
 		dst.valType = src.valType
 	}
-	if src.key != "" {
+	if src.hasKey {
 		dst.key = src.key
+		dst.hasKey = true
 	}
 	if src.reload {
 		dst.reload = true
 	}
-	if src.optional {
-		dst.optional = true
+	if src.hasOptional {
+		dst.optional = src.optional
+		dst.hasOptional = true
+	}
+	if src.group {
+		dst.group = true
+	}
+	if src.config != "" {
+		dst.config = src.config
+	}
+	if src.inline {
+		dst.inline = true
+	}
+	if src.hasDefault {
+		dst.defaultValue = src.defaultValue
+		dst.hasDefault = true
+	}
+}
+
+// applyInjectFuncOpt returns tag overridden by every option set in opt.
+func applyInjectFuncOpt(tag injectTag, opt *injectFuncOption) injectTag {
+	if opt.valType != "" {
+		tag.valType = opt.valType
+	}
+	if opt.hasKey {
+		tag.key = opt.key
+	}
+	if opt.reload {
+		tag.reload = true
+	}
+	if opt.hasOptional {
+		tag.optional = opt.optional
+	}
+	if opt.group {
+		tag.group = true
+	}
+	if opt.config != "" {
+		tag.config = opt.config
+	}
+	if opt.inline {
+		tag.inline = true
+	}
+	if opt.hasDefault {
+		tag.defaultValue = opt.defaultValue
+		tag.hasDefault = true
 	}
+	return tag
 }
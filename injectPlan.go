@@ -0,0 +1,145 @@
+package dix
+
+import (
+	"reflect"
+)
+
+// structPlan is the compiled injection plan of a struct type, so tags are parsed once per type.
+type structPlan struct {
+	fields []fieldPlan
+
+	// set if an inline field leads back to a struct type it is nested in, which would be injected forever
+	cycleField string
+	cycleType  reflect.Type
+}
+
+type fieldPlan struct {
+	index int
+	name  string
+	typ   reflect.Type
+	tag   injectTag
+
+	exported   bool
+	skip       bool // di:"-"
+	autoInline bool // embedded struct / pointer-to-struct without tag
+
+	// set if the tag is malformed or contradictory, reported in strict mode
+	invalidToken  string
+	invalidReason string
+}
+
+// funcPlan is the compiled injection plan of a func type.
+type funcPlan struct {
+	in                   []reflect.Type
+	variableNameIndexMap map[string]int
+	hasCtx               bool // first param is a context.Context
+	inStructs            []bool
+	hasErr               bool // last result is an error
+}
+
+func getStructPlan(t reflect.Type) *structPlan {
+	if !Container.injectPlanCache {
+		return compileStructPlan(t)
+	}
+	if plan, exist := Container.structPlanMap.Get(t); exist {
+		return plan
+	}
+	plan, _ := Container.structPlanMap.GetOrSet(t, func() *structPlan {
+		return compileStructPlan(t)
+	})
+	return plan
+}
+
+func compileStructPlan(t reflect.Type) *structPlan {
+	plan := &structPlan{
+		fields: make([]fieldPlan, 0, t.NumField()),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type == inType {
+			continue
+		}
+
+		tag := field.Tag.Get(injectStructTag)
+		invalidToken, invalidReason := validateDITag(tag, field.Type)
+		plan.fields = append(plan.fields, fieldPlan{
+			index:         i,
+			name:          field.Name,
+			typ:           field.Type,
+			tag:           parseDITag(tag),
+			exported:      field.IsExported(),
+			skip:          tag == "-",
+			autoInline:    isAutoInline(field, tag),
+			invalidToken:  invalidToken,
+			invalidReason: invalidReason,
+		})
+	}
+	plan.cycleField, plan.cycleType = findInlineCycle(t, "", map[reflect.Type]struct{}{t: {}})
+	return plan
+}
+
+// isAutoInline reports whether field is an embedded struct or pointer-to-struct without tag, injected like inline.
+func isAutoInline(field reflect.StructField, tag string) bool {
+	return field.Anonymous && tag == "" && isStructOrStructPtr(field.Type)
+}
+
+// findInlineCycle returns the path and type of the first inline field of t, or of its inline structs,
+// whose struct type is in stack, e.g. type Node struct{ *Node }.
+func findInlineCycle(t reflect.Type, path string, stack map[reflect.Type]struct{}) (string, reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type == inType {
+			continue
+		}
+		tag := field.Tag.Get(injectStructTag)
+		if !isStructOrStructPtr(field.Type) || !(isAutoInline(field, tag) || parseDITag(tag).inline) {
+			continue
+		}
+
+		fieldPath := joinFieldPath(path, field.Name)
+		typ := field.Type
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if _, exist := stack[typ]; exist {
+			return fieldPath, field.Type
+		}
+
+		stack[typ] = struct{}{}
+		if cycleField, cycleType := findInlineCycle(typ, fieldPath, stack); cycleField != "" {
+			return cycleField, cycleType
+		}
+		delete(stack, typ)
+	}
+	return "", nil
+}
+
+func getFuncPlan(t reflect.Type) *funcPlan {
+	if !Container.injectPlanCache {
+		return compileFuncPlan(t)
+	}
+	if plan, exist := Container.funcPlanMap.Get(t); exist {
+		return plan
+	}
+	plan, _ := Container.funcPlanMap.GetOrSet(t, func() *funcPlan {
+		return compileFuncPlan(t)
+	})
+	return plan
+}
+
+func compileFuncPlan(t reflect.Type) *funcPlan {
+	plan := &funcPlan{
+		in:                   make([]reflect.Type, t.NumIn()),
+		variableNameIndexMap: make(map[string]int, t.NumIn()),
+		inStructs:            make([]bool, t.NumIn()),
+		hasErr:               t.NumOut() > 0 && t.Out(t.NumOut()-1) == errorType,
+	}
+	for i := 0; i < t.NumIn(); i++ {
+		paramType := t.In(i)
+		plan.in[i] = paramType
+		plan.variableNameIndexMap[paramType.Name()] = i
+		plan.inStructs[i] = isInStruct(paramType)
+	}
+	plan.hasCtx = t.NumIn() > 0 && plan.in[0] == contextType
+	return plan
+}
@@ -5,115 +5,303 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
-)
-
-const injectStructTag = "di"
 
-var injectTagFlagType injectTagFlag = "type"
-var injectTagFlagKey injectTagFlag = "key"
-var injectTagFlagReload injectTagFlag = "reload"
-var injectTagFlagOptional injectTagFlag = "optional"
+	"github.com/jbterrylin/dix/internal/ditag"
+)
 
-var injectTagFlagTypeOptProvider injectTagFlagTypeOpt = "provider"
+const injectStructTag = ditag.Name
 
 type injectTag struct {
 	valType  string
 	key      string
 	reload   bool
 	optional bool
+	group    bool
+	config   string // config path, e.g. database.dsn
+	inline   bool   // only for struct fields
+
+	// literal used when nothing is found, e.g. 30s
+	defaultValue string
+	hasDefault   bool
 }
 
-func newInjectTag(valType string, key string, reload bool, optional bool) injectTag {
+func newInjectTag(valType string, key string, reload bool, optional bool, group bool, config string, inline bool, defaultValue string, hasDefault bool) injectTag {
 	return injectTag{
-		valType:  valType,
-		key:      key,
-		reload:   reload,
-		optional: optional,
+		valType:      valType,
+		key:          key,
+		reload:       reload,
+		optional:     optional,
+		group:        group,
+		config:       config,
+		inline:       inline,
+		defaultValue: defaultValue,
+		hasDefault:   hasDefault,
 	}
 }
 
-func InjectStruct(target any) error {
-	return InjectStructWithCtx(context.Background(), target)
+// Field options override the `di` tag of the same field. See Field and SkipField.
+// If target implements PostInjector, it is called once every field is injected.
+func InjectStruct(target any, opts ...InjectStructOption) error {
+	return InjectStructWithCtx(context.Background(), target, opts...)
 }
 
-func InjectStructWithCtx(ctx context.Context, target any) error {
+func InjectStructWithCtx(ctx context.Context, target any, opts ...InjectStructOption) error {
 	v := reflect.ValueOf(target)
 	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
 		return ErrInjectStructMustBePointerStruct
 	}
 
-	t := v.Elem().Type()
-	v = v.Elem()
+	// handle options
+	opt := newInjectStructOption()
+	for _, o := range opts {
+		o(opt)
+	}
+
+	if err := injectStructValue(ctx, v.Elem(), "", opt); err != nil {
+		return err
+	}
+
+	// every option must match a field, reported sorted by name so the error does not depend on map order
+	var unused []string
+	for name := range opt.fieldOptMap {
+		if _, used := opt.usedFieldMap[name]; !used {
+			unused = append(unused, name)
+		}
+	}
+	for name := range opt.skipFieldMap {
+		if _, used := opt.usedFieldMap[name]; !used {
+			unused = append(unused, name)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return fmt.Errorf("failed at field name=%v: %w", unused[0], ErrFieldNotFound)
+	}
+
+	if err := postInject(ctx, target); err != nil {
+		return err
+	}
+	addPostInjected(ctx, target)
+	return nil
+}
+
+// ValidateStruct checks every `di` tag of T, or of the struct T points to, including inline structs.
+// It reports the tag and unexported field errors InjectStruct returns in strict mode, joined, without resolving anything.
+func ValidateStruct[T any]() error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return ErrInjectStructMustBePointerStruct
+	}
+
+	errs := validateStructType(t, "", make(map[reflect.Type]struct{}))
+	if plan := compileStructPlan(t); plan.cycleField != "" {
+		errs = append(errs, fmt.Errorf("failed at field name=%v, field type=%v: %w", plan.cycleField, plan.cycleType, ErrInlineCycle))
+	}
+	return errors.Join(errs...)
+}
+
+func validateStructType(t reflect.Type, path string, visited map[reflect.Type]struct{}) []error {
+	if _, exist := visited[t]; exist {
+		return nil
+	}
+	visited[t] = struct{}{}
 
-	for i := 0; i < t.NumField(); i++ {
-		field := t.Field(i)
-		tag := field.Tag.Get(injectStructTag)
-		if tag == "-" {
+	var errs []error
+	for _, field := range compileStructPlan(t).fields {
+		if field.skip {
+			continue
+		}
+		fieldPath := joinFieldPath(path, field.name)
+		if field.invalidReason != "" {
+			errs = append(errs, newInvalidTagError(t, fieldPath, field.invalidToken, field.invalidReason))
 			continue
 		}
+		if field.tag.inline || field.autoInline {
+			typ := field.typ
+			if typ.Kind() == reflect.Ptr {
+				typ = typ.Elem()
+			}
+			errs = append(errs, validateStructType(typ, fieldPath, visited)...)
+			continue
+		}
+		if !field.exported {
+			errs = append(errs, fmt.Errorf("failed at field name=%v, field type=%v: %w", fieldPath, field.typ, ErrFieldCannotBeSet))
+		}
+	}
+	return errs
+}
 
-		// parse tag
-		injectTag := parseDITag(tag)
+// newInjectedStruct builds a zero struct t, or a new struct t points to, injected by InjectStructWithCtx.
+func newInjectedStruct(ctx context.Context, t reflect.Type) (any, error) {
+	if t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		if err := InjectStructWithCtx(ctx, v.Interface()); err != nil {
+			return nil, err
+		}
+		return v.Interface(), nil
+	}
 
-		fieldVal := v.Field(i)
-		if !fieldVal.CanSet() {
-			return fmt.Errorf("failed at field name=%v, field type=%v: %w", field.Name, field.Type, ErrFieldCannotBeSet)
+	v := reflect.New(t)
+	if err := InjectStructWithCtx(ctx, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// injectStructValue injects every field of the struct v, which must be addressable.
+// path is the field path of v from the injected target, used in error messages and to match opt.
+func injectStructValue(ctx context.Context, v reflect.Value, path string, opt *injectStructOption) error {
+	plan := getStructPlan(v.Type())
+	if plan.cycleField != "" {
+		return fmt.Errorf("failed at field name=%v, field type=%v: %w", joinFieldPath(path, plan.cycleField), plan.cycleType, ErrInlineCycle)
+	}
+	hasOpt := !opt.isEmpty()
+
+	for i := range plan.fields {
+		field := &plan.fields[i]
+		injectTag := field.tag
+		skip := field.skip
+		inline := field.tag.inline || field.autoInline
+
+		if hasOpt {
+			fieldPath := joinFieldPath(path, field.name)
+			if _, exist := opt.skipFieldMap[fieldPath]; exist {
+				opt.usedFieldMap[fieldPath] = struct{}{}
+				continue
+			}
+			if fieldOpt, exist := opt.fieldOptMap[fieldPath]; exist {
+				opt.usedFieldMap[fieldPath] = struct{}{}
+				injectTag = applyInjectFuncOpt(injectTag, fieldOpt)
+				skip = false
+				inline = injectTag.inline
+			}
+		}
+		if skip {
+			continue
+		}
+		if Container.strictTag && field.invalidReason != "" {
+			return newInvalidTagError(v.Type(), joinFieldPath(path, field.name), field.invalidToken, field.invalidReason)
 		}
 
-		typ := field.Type
+		fieldVal := v.Field(field.index)
 
-		// determine injection path
-		var err error
+		// embedded structs without tag are injected recursively too
+		if inline {
+			if err := injectInlineField(ctx, fieldVal, joinFieldPath(path, field.name), opt); err != nil {
+				return err
+			}
+			continue
+		}
 
-		var tmp *reflect.Value
-		switch injectTag.valType {
-		case injectTagFlagTypeOptProvider.Value():
-			tmp, err = getFromProvider(ctx, typ, injectTag)
-		default:
-			tmp, err = getFromValue(typ, injectTag)
+		if !fieldVal.CanSet() {
+			return fmt.Errorf("failed at field name=%v, field type=%v: %w", joinFieldPath(path, field.name), field.typ, ErrFieldCannotBeSet)
 		}
 
+		tmp, err := resolveInjectTag(ctx, field.typ, injectTag)
+		if err != nil && opt.awaitMissing && !injectTag.optional && errors.Is(err, ErrValueNotFound) {
+			tmp, err = awaitInjectTag(ctx, field.typ, injectTag)
+		}
 		if err != nil {
 			if errors.Is(err, ErrValueNotFound) && injectTag.optional {
 				continue
 			}
-			return fmt.Errorf("failed at field name=%v, field type=%v: %w", field.Name, field.Type, err)
+			return fmt.Errorf("failed at field name=%v, field type=%v: %w", joinFieldPath(path, field.name), field.typ, err)
 		}
 		fieldVal.Set(*tmp)
 	}
 	return nil
 }
 
-func parseDITag(tag string) injectTag {
-	opts := make(map[string]string)
-	for _, part := range strings.Split(tag, ";") {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
-		}
-		if strings.Contains(part, ":") {
-			kv := strings.SplitN(part, ":", 2)
-			opts[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
-		} else {
-			switch part {
-			case injectTagFlagReload.Value(),
-				injectTagFlagOptional.Value():
-				opts[part] = "true"
+// injectInlineField descends into a struct or pointer-to-struct field, allocating it if nil.
+func injectInlineField(ctx context.Context, fieldVal reflect.Value, fieldPath string, opt *injectStructOption) error {
+	if !isStructOrStructPtr(fieldVal.Type()) {
+		return fmt.Errorf("failed at field name=%v, field type=%v: %w", fieldPath, fieldVal.Type(), ErrInjectStructMustBePointerStruct)
+	}
+
+	if fieldVal.Kind() == reflect.Ptr {
+		if fieldVal.IsNil() {
+			if !fieldVal.CanSet() {
+				return fmt.Errorf("failed at field name=%v, field type=%v: %w", fieldPath, fieldVal.Type(), ErrFieldCannotBeSet)
 			}
+			fieldVal.Set(reflect.New(fieldVal.Type().Elem()))
 		}
+		fieldVal = fieldVal.Elem()
 	}
 
-	return newInjectTag(
-		opts[injectTagFlagType.Value()],
-		opts[injectTagFlagKey.Value()],
-		strings.ToLower(opts[injectTagFlagReload.Value()]) == "true",
-		strings.ToLower(opts[injectTagFlagOptional.Value()]) == "true",
-	)
+	return injectStructValue(ctx, fieldVal, fieldPath, opt)
+}
+
+func isStructOrStructPtr(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct || (t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct)
+}
+
+func joinFieldPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func parseDITag(tag string) injectTag {
+	t := ditag.Parse(tag)
+	return newInjectTag(t.Type, t.Key, t.Reload, t.Optional, t.Group, t.Config, t.Inline, t.Default, t.HasDefault)
+}
+
+// validateDITag returns the offending token and the reason if tag is malformed, or contradictory for a field of typ.
+func validateDITag(tag string, typ reflect.Type) (token string, reason string) {
+	if token, reason := ditag.Validate(tag); reason != "" {
+		return token, reason
+	}
+
+	t := ditag.Parse(tag)
+	switch {
+	case t.Inline && !isStructOrStructPtr(typ):
+		return t.Tokens[ditag.OptInline], "inline field must be a struct or pointer to struct"
+	case t.Group && typ.Kind() != reflect.Slice && !(typ.Kind() == reflect.Map && typ.Key().Kind() == reflect.String):
+		return t.Tokens[ditag.OptGroup], "group field must be a slice or map[string]"
+	case t.HasDefault && decodeLiteral(t.Default, reflect.New(typ).Elem()) != nil:
+		return t.Tokens[ditag.OptDefault], "default cannot be decoded into the field type"
+	}
+	return "", ""
+}
+
+// resolveInjectTag determines the injection path of tag and resolves a typ from it.
+// If nothing is found, the default literal of tag is decoded into typ, if any.
+func resolveInjectTag(ctx context.Context, typ reflect.Type, tag injectTag) (*reflect.Value, error) {
+	tmp, err := resolveInjectSource(ctx, typ, tag)
+	if err != nil && tag.hasDefault && errors.Is(err, ErrValueNotFound) {
+		return decodeInjectDefault(typ, tag.defaultValue)
+	}
+	return tmp, err
+}
+
+func decodeInjectDefault(typ reflect.Type, defaultValue string) (*reflect.Value, error) {
+	tmp := reflect.New(typ).Elem()
+	if err := decodeLiteral(defaultValue, tmp); err != nil {
+		return nil, fmt.Errorf("failed at default=%v: %w", defaultValue, err)
+	}
+	return &tmp, nil
+}
+
+func resolveInjectSource(ctx context.Context, typ reflect.Type, tag injectTag) (*reflect.Value, error) {
+	switch {
+	case tag.group:
+		return getFromGroup(ctx, typ)
+	case tag.config != "":
+		return getFromConfig(typ, tag.config)
+	case tag.valType == ditag.TypeProvider:
+		return getFromProvider(ctx, typ, tag)
+	default:
+		return getFromValue(typ, tag)
+	}
 }
 
 func getFromProvider(ctx context.Context, typ reflect.Type, opts injectTag) (*reflect.Value, error) {
-	key := ProviderKey(opts.key)
 	reload := opts.reload
 
 	providerGetOptions := []ProviderGetOption{}
@@ -122,7 +310,27 @@ func getFromProvider(ctx context.Context, typ reflect.Type, opts injectTag) (*re
 		providerGetOptions = append(providerGetOptions, WithProviderReload())
 	}
 
-	_, val, err := getProviderByTypeKey(ctx, typ, key, providerGetOptions...)
+	if !strings.Contains(opts.key, ditag.KeySeparator) {
+		return getFromProviderByKey(ctx, typ, ProviderKey(opts.key), providerGetOptions...)
+	}
+
+	// fallback keys, the first found wins. An empty one means the default key
+	for _, key := range ditag.Keys(opts.key) {
+		providerKey := ProviderKey(key)
+		if key == "" {
+			providerKey = DefaultProviderKey
+		}
+		tmp, err := getFromProviderByKey(ctx, typ, providerKey, providerGetOptions...)
+		if errors.Is(err, ErrValueNotFound) {
+			continue
+		}
+		return tmp, err
+	}
+	return nil, ErrValueNotFound
+}
+
+func getFromProviderByKey(ctx context.Context, typ reflect.Type, key ProviderKey, opts ...ProviderGetOption) (*reflect.Value, error) {
+	_, val, err := getProviderByTypeKey(ctx, typ, key, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -132,7 +340,26 @@ func getFromProvider(ctx context.Context, typ reflect.Type, opts injectTag) (*re
 }
 
 func getFromValue(typ reflect.Type, opts injectTag) (*reflect.Value, error) {
-	key := ValueKey(opts.key)
+	if !strings.Contains(opts.key, ditag.KeySeparator) {
+		return getFromValueByKey(typ, ValueKey(opts.key))
+	}
+
+	// fallback keys, the first found wins. An empty one means the default key
+	for _, key := range ditag.Keys(opts.key) {
+		valueKey := ValueKey(key)
+		if key == "" {
+			valueKey = DefaultValueKey
+		}
+		tmp, err := getFromValueByKey(typ, valueKey)
+		if errors.Is(err, ErrValueNotFound) {
+			continue
+		}
+		return tmp, err
+	}
+	return nil, ErrValueNotFound
+}
+
+func getFromValueByKey(typ reflect.Type, key ValueKey) (*reflect.Value, error) {
 	value, err := getByTypeKey(typ, key)
 	if err != nil {
 		return nil, err
@@ -1,8 +1,26 @@
 package dix
 
+import "context"
+
 type iContainerData interface {
 	setAccessed()
 	lock()
 	unlock()
-	triggerOnCloseHook()
+	// closer is called holding the lock, and returns the close to run after unlocking, nil if there is none.
+	closer() func(ctx context.Context, forceClose bool) (refErr error, closeErr error)
+	needClose() bool
+	GetTagMap() map[string]any
+	refCount() int64
+	order() (priority int, seq uint64)
+}
+
+// PostInjector is called by InjectStruct once every field is injected,
+// and on the value returned by a provider's factory function.
+type PostInjector interface {
+	PostInject(ctx context.Context) error
+}
+
+// PreCloser is called before the OnCloseHook of a value or cached provider value.
+type PreCloser interface {
+	PreClose(ctx context.Context) error
 }
@@ -0,0 +1,147 @@
+// Package ditag parses `di` struct tags. It is shared by dix and its tools, so they agree on the grammar.
+package ditag
+
+import (
+	"strings"
+)
+
+// Name is the struct tag name.
+const Name = "di"
+
+// Skip is the tag of fields that are not injected.
+const Skip = "-"
+
+const (
+	OptType     = "type"
+	OptKey      = "key"
+	OptReload   = "reload"
+	OptOptional = "optional"
+	OptGroup    = "group"
+	OptConfig   = "config"
+	OptInline   = "inline"
+	OptDefault  = "default"
+)
+
+const (
+	TypeProvider = "provider"
+	TypeValue    = "value"
+)
+
+// KeySeparator separates fallback keys, e.g. key:replica|main|
+const KeySeparator = "|"
+
+type Tag struct {
+	Type     string
+	Key      string
+	Reload   bool
+	Optional bool
+	Group    bool
+	Config   string // config path, e.g. database.dsn
+	Inline   bool
+
+	// literal used when nothing is found, e.g. 30s
+	Default    string
+	HasDefault bool
+
+	// option name to its token as written, e.g. reload -> reload:true
+	Tokens map[string]string
+}
+
+// Parse parses tag leniently, unknown options and invalid bools are ignored.
+func Parse(tag string) Tag {
+	opts := make(map[string]string)
+	tokens := make(map[string]string)
+	for _, part := range strings.Split(tag, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if strings.Contains(part, ":") {
+			kv := strings.SplitN(part, ":", 2)
+			opts[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+			tokens[strings.TrimSpace(kv[0])] = part
+		} else {
+			switch part {
+			case OptReload, OptOptional, OptGroup, OptInline:
+				opts[part] = "true"
+				tokens[part] = part
+			}
+		}
+	}
+
+	defaultValue, hasDefault := opts[OptDefault]
+
+	return Tag{
+		Type:       opts[OptType],
+		Key:        opts[OptKey],
+		Reload:     strings.ToLower(opts[OptReload]) == "true",
+		Optional:   strings.ToLower(opts[OptOptional]) == "true",
+		Group:      strings.ToLower(opts[OptGroup]) == "true",
+		Config:     opts[OptConfig],
+		Inline:     strings.ToLower(opts[OptInline]) == "true",
+		Default:    defaultValue,
+		HasDefault: hasDefault,
+		Tokens:     tokens,
+	}
+}
+
+// Validate returns the offending token and the reason if tag is malformed or contradictory.
+// Checks depending on the field type are left to the caller.
+func Validate(tag string) (token string, reason string) {
+	if tag == Skip {
+		return "", ""
+	}
+
+	tokens := make(map[string]string)
+	for _, part := range strings.Split(tag, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, hasValue := strings.Cut(part, ":")
+		k = strings.TrimSpace(k)
+		v = strings.TrimSpace(v)
+
+		switch k {
+		case OptType:
+			if v != TypeProvider && v != TypeValue {
+				return part, "unknown type"
+			}
+		case OptKey, OptConfig, OptDefault:
+			if !hasValue {
+				return part, "missing value"
+			}
+		case OptReload, OptOptional, OptGroup, OptInline:
+			if hasValue && !strings.EqualFold(v, "true") && !strings.EqualFold(v, "false") {
+				return part, "invalid bool"
+			}
+		default:
+			return part, "unknown option"
+		}
+
+		if _, exist := tokens[k]; exist {
+			return part, "duplicate option"
+		}
+		tokens[k] = part
+	}
+
+	t := Parse(tag)
+	switch {
+	case t.Inline && len(tokens) > 1:
+		return tokens[OptInline], "inline cannot be used with other options"
+	case t.Group && (t.Type != "" || t.Key != "" || t.Config != "" || t.Reload):
+		return tokens[OptGroup], "group cannot be used with type, key, config or reload"
+	case t.Config != "" && (t.Type != "" || t.Key != "" || t.Reload):
+		return tokens[OptConfig], "config cannot be used with type, key or reload"
+	case t.Reload && t.Type != TypeProvider:
+		return tokens[OptReload], "reload is only for providers"
+	case t.HasDefault && t.Group:
+		return tokens[OptDefault], "default cannot be used with group or inline"
+	}
+	return "", ""
+}
+
+// Keys splits fallback keys. An empty key means the default key.
+func Keys(key string) []string {
+	return strings.Split(key, KeySeparator)
+}
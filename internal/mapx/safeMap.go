@@ -49,6 +49,15 @@ func (s *SafeMap[Key, Value]) Set(key Key, val Value) {
 	s.lock.Unlock()
 }
 
+// SetMany 在同一把锁内插入或更新多个 key，读者不会看到只更新了一部分的状态
+func (s *SafeMap[Key, Value]) SetMany(keys []Key, val Value) {
+	s.lock.Lock()
+	for _, key := range keys {
+		s.m[key] = val
+	}
+	s.lock.Unlock()
+}
+
 // Get 读取
 func (s *SafeMap[Key, Value]) Get(key Key) (Value, bool) {
 	s.lock.RLock()
@@ -1,25 +1,63 @@
 package dix
 
+import (
+	"context"
+	"strings"
+
+	"github.com/jbterrylin/dix/internal/ditag"
+)
+
 type valueAddOption struct {
-	onCloseHook func()
-	setDefault  bool
-	tagMap      map[string]any
+	onCloseHook   func(context.Context) error
+	skipAutoClose bool
+	setDefault    bool
+	priority      int
+	tagMap        map[string]any
 }
 
 type ValueAddOption func(*valueAddOption)
 
 func WithValueOnClose(f func()) ValueAddOption {
+	return func(o *valueAddOption) {
+		if f == nil {
+			o.onCloseHook = nil
+			return
+		}
+		o.onCloseHook = func(context.Context) error {
+			f()
+			return nil
+		}
+	}
+}
+
+func WithValueOnCloseCtx(f func(ctx context.Context) error) ValueAddOption {
 	return func(o *valueAddOption) {
 		o.onCloseHook = f
 	}
 }
 
+// WithValueSkipAutoClose stops a value implementing io.Closer or Close(ctx) error
+// from being used as its own OnCloseHook.
+func WithValueSkipAutoClose() ValueAddOption {
+	return func(o *valueAddOption) {
+		o.skipAutoClose = true
+	}
+}
+
 func WithValueSetDefault() ValueAddOption {
 	return func(o *valueAddOption) {
 		o.setDefault = true
 	}
 }
 
+// WithValuePriority sorts the value before every value of the same type with a lower priority
+// in ListKeys and GetAll. Values with the same priority keep registration order.
+func WithValuePriority(priority int) ValueAddOption {
+	return func(o *valueAddOption) {
+		o.priority = priority
+	}
+}
+
 func WithValueTag(tagMap map[string]any) ValueAddOption {
 	return func(o *valueAddOption) {
 		o.tagMap = tagMap
@@ -28,6 +66,7 @@ func WithValueTag(tagMap map[string]any) ValueAddOption {
 
 type valueDeleteOption struct {
 	skipOnClose bool
+	forceClose  bool
 }
 
 type ValueDeleteOption func(*valueDeleteOption)
@@ -38,9 +77,18 @@ func WithValueSkipOnClose() ValueDeleteOption {
 	}
 }
 
+// WithValueForceClose runs the OnCloseHook even if ctx ends before the ref counter reaches zero.
+// Without it, the OnCloseHook is skipped in that case.
+func WithValueForceClose() ValueDeleteOption {
+	return func(o *valueDeleteOption) {
+		o.forceClose = true
+	}
+}
+
 type providerAddOption struct {
 	setDefault bool
 	noCache    bool
+	priority   int
 	tagMap     map[string]any
 }
 
@@ -58,6 +106,14 @@ func WithProviderNoCache() ProviderAddOption {
 	}
 }
 
+// WithProviderPriority sorts the provider before every provider of the same type with a lower priority
+// in ListProviderKeys and GetAllProvider. Providers with the same priority keep registration order.
+func WithProviderPriority(priority int) ProviderAddOption {
+	return func(o *providerAddOption) {
+		o.priority = priority
+	}
+}
+
 func WithProviderTag(tagMap map[string]any) ProviderAddOption {
 	return func(o *providerAddOption) {
 		o.tagMap = tagMap
@@ -76,13 +132,89 @@ func WithProviderReload() ProviderGetOption {
 	}
 }
 
+type contributeOption struct {
+	name          string
+	priority      int
+	noCache       bool
+	onCloseHook   func(context.Context) error
+	skipAutoClose bool
+	tagMap        map[string]any
+}
+
+type ContributeOption func(*contributeOption)
+
+// WithContributeName names the contribution, which makes it part of GetMap.
+// A later contribution with the same name replaces the earlier one.
+func WithContributeName(name string) ContributeOption {
+	return func(o *contributeOption) {
+		o.name = name
+	}
+}
+
+// WithContributePriority sorts the contribution before every contribution with a lower priority.
+// Contributions with the same priority keep registration order.
+func WithContributePriority(priority int) ContributeOption {
+	return func(o *contributeOption) {
+		o.priority = priority
+	}
+}
+
+// Only for ContributeProvider and ContributeCtxProvider.
+func WithContributeNoCache() ContributeOption {
+	return func(o *contributeOption) {
+		o.noCache = true
+	}
+}
+
+// Only for Contribute.
+func WithContributeOnCloseCtx(f func(ctx context.Context) error) ContributeOption {
+	return func(o *contributeOption) {
+		o.onCloseHook = f
+	}
+}
+
+// Only for Contribute.
+func WithContributeSkipAutoClose() ContributeOption {
+	return func(o *contributeOption) {
+		o.skipAutoClose = true
+	}
+}
+
+func WithContributeTag(tagMap map[string]any) ContributeOption {
+	return func(o *contributeOption) {
+		o.tagMap = tagMap
+	}
+}
+
+type configOption struct {
+	setDefault bool
+}
+
+type ConfigOption func(*configOption)
+
+// WithConfigSetDefault also registers the config as the default value of T, replacing any default T.
+func WithConfigSetDefault() ConfigOption {
+	return func(o *configOption) {
+		o.setDefault = true
+	}
+}
+
 type injectFuncOption struct {
-	variable string // can be variable name / index
+	variable string // can be param index / param type name
 
 	valType  string // provider / value
 	key      string
+	hasKey   bool // key is set, even to the default key
 	reload   bool
 	optional bool
+	group    bool
+	config   string
+	inline   bool // only for InjectStruct
+
+	hasOptional bool // optional is set, even to false
+
+	defaultValue string
+	hasDefault   bool
 }
 
 type InjectFuncOption func(*injectFuncOption)
@@ -90,7 +222,7 @@ type InjectFuncOption func(*injectFuncOption)
 func WithInjectFuncProvider(variable string) InjectFuncOption {
 	return func(o *injectFuncOption) {
 		o.variable = variable
-		o.valType = "provider"
+		o.valType = ditag.TypeProvider
 	}
 }
 
@@ -98,6 +230,25 @@ func WithInjectFuncKey(variable string, key string) InjectFuncOption {
 	return func(o *injectFuncOption) {
 		o.variable = variable
 		o.key = key
+		o.hasKey = true
+	}
+}
+
+// WithInjectFuncKeys tries keys in order, the first found wins. An empty key means the default key.
+func WithInjectFuncKeys(variable string, keys ...string) InjectFuncOption {
+	return func(o *injectFuncOption) {
+		o.variable = variable
+		o.key = strings.Join(keys, ditag.KeySeparator)
+		o.hasKey = true
+	}
+}
+
+// WithInjectFuncDefault decodes literal into the param if nothing is found, e.g. "30s" for a time.Duration.
+func WithInjectFuncDefault(variable string, literal string) InjectFuncOption {
+	return func(o *injectFuncOption) {
+		o.variable = variable
+		o.defaultValue = literal
+		o.hasDefault = true
 	}
 }
 
@@ -112,11 +263,174 @@ func WithInjectFuncOptional(variable string) InjectFuncOption {
 	return func(o *injectFuncOption) {
 		o.variable = variable
 		o.optional = true
+		o.hasOptional = true
+	}
+}
+
+// WithInjectFuncGroup resolves a []T or map[string]T param from the contributions of T.
+func WithInjectFuncGroup(variable string) InjectFuncOption {
+	return func(o *injectFuncOption) {
+		o.variable = variable
+		o.group = true
+	}
+}
+
+// WithInjectFuncConfig resolves the param from a single config leaf, e.g. "database.dsn".
+func WithInjectFuncConfig(variable string, path string) InjectFuncOption {
+	return func(o *injectFuncOption) {
+		o.variable = variable
+		o.config = path
+	}
+}
+
+type injectStructOption struct {
+	fieldOptMap  map[string]*injectFuncOption
+	skipFieldMap map[string]struct{}
+
+	// matched field names, to report options matching no field
+	usedFieldMap map[string]struct{}
+
+	awaitMissing bool
+}
+
+// Maps are allocated by the options, so injecting without options costs nothing.
+func newInjectStructOption() *injectStructOption {
+	return &injectStructOption{}
+}
+
+func (o *injectStructOption) init() {
+	if o.fieldOptMap == nil {
+		o.fieldOptMap = make(map[string]*injectFuncOption)
+		o.skipFieldMap = make(map[string]struct{})
+		o.usedFieldMap = make(map[string]struct{})
+	}
+}
+
+func (o *injectStructOption) isEmpty() bool {
+	return len(o.fieldOptMap) == 0 && len(o.skipFieldMap) == 0
+}
+
+type InjectStructOption func(*injectStructOption)
+
+// Field configures the injection of a field without a `di` tag, or overrides its tag.
+// name is the field path from the injected target, e.g. "Store" or "Server.Store" for inline structs.
+func Field(name string, opts ...FieldOption) InjectStructOption {
+	return func(o *injectStructOption) {
+		o.init()
+		tmp, exist := o.fieldOptMap[name]
+		if !exist {
+			tmp = &injectFuncOption{variable: name}
+			o.fieldOptMap[name] = tmp
+		}
+		for _, opt := range opts {
+			opt(tmp)
+		}
+	}
+}
+
+// SkipField is the same as a `di:"-"` tag.
+func SkipField(name string) InjectStructOption {
+	return func(o *injectStructOption) {
+		o.init()
+		o.skipFieldMap[name] = struct{}{}
+	}
+}
+
+// AwaitMissing makes InjectStructWithCtx wait until missing values and providers are added, instead of failing
+// with ErrValueNotFound, until its ctx ends. Optional fields, fields with a default, and providers found but failing
+// are not waited for.
+func AwaitMissing() InjectStructOption {
+	return func(o *injectStructOption) {
+		o.awaitMissing = true
+	}
+}
+
+// FieldOption is the option form of a `di` tag option.
+type FieldOption func(*injectFuncOption)
+
+func FromProvider() FieldOption {
+	return func(o *injectFuncOption) {
+		o.valType = ditag.TypeProvider
+	}
+}
+
+// FromValue resolves the field from values, overriding a `type:provider` tag.
+func FromValue() FieldOption {
+	return func(o *injectFuncOption) {
+		o.valType = ditag.TypeValue
+	}
+}
+
+// WithKey overrides the key of the tag. An empty key means the default key.
+func WithKey(key string) FieldOption {
+	return func(o *injectFuncOption) {
+		o.key = key
+		o.hasKey = true
+	}
+}
+
+// WithKeys tries keys in order, the first found wins. An empty key means the default key.
+func WithKeys(keys ...string) FieldOption {
+	return func(o *injectFuncOption) {
+		o.key = strings.Join(keys, ditag.KeySeparator)
+		o.hasKey = true
+	}
+}
+
+// WithDefaultKey resolves the field from the default key, dropping the keys of the tag.
+func WithDefaultKey() FieldOption {
+	return WithKey("")
+}
+
+func WithDefault(literal string) FieldOption {
+	return func(o *injectFuncOption) {
+		o.defaultValue = literal
+		o.hasDefault = true
+	}
+}
+
+func WithReload() FieldOption {
+	return func(o *injectFuncOption) {
+		o.reload = true
+	}
+}
+
+func WithOptional() FieldOption {
+	return func(o *injectFuncOption) {
+		o.optional = true
+		o.hasOptional = true
+	}
+}
+
+// WithRequired fails when nothing is found, overriding an `optional` tag.
+func WithRequired() FieldOption {
+	return func(o *injectFuncOption) {
+		o.optional = false
+		o.hasOptional = true
+	}
+}
+
+func WithGroup() FieldOption {
+	return func(o *injectFuncOption) {
+		o.group = true
+	}
+}
+
+func WithConfig(path string) FieldOption {
+	return func(o *injectFuncOption) {
+		o.config = path
+	}
+}
+
+func WithInline() FieldOption {
+	return func(o *injectFuncOption) {
+		o.inline = true
 	}
 }
 
 type resetOption struct {
 	skipOnClose bool
+	forceClose  bool
 }
 
 type ResetOption func(*resetOption)
@@ -126,3 +440,11 @@ func WithResetSkipOnClose() ResetOption {
 		o.skipOnClose = true
 	}
 }
+
+// WithResetForceClose runs the OnCloseHook even if ctx ends before the ref counter reaches zero.
+// Without it, the OnCloseHook is skipped in that case.
+func WithResetForceClose() ResetOption {
+	return func(o *resetOption) {
+		o.forceClose = true
+	}
+}
@@ -0,0 +1,55 @@
+package dix
+
+import "reflect"
+
+// In marks a param struct of InjectFunc, Invoke or Provide.
+// Each field of the struct is injected with its `di` tag, exactly like InjectStruct.
+//
+//	func(p struct {
+//		dix.In
+//		Main    *sql.DB `di:"key:main"`
+//		Replica *sql.DB `di:"key:replica"`
+//	}) { ... }
+type In struct{}
+
+// Out marks a result struct of a Provide constructor.
+// Each exported field is registered as its own provider under its type and `di:"key:..."` tag
+// (the key given to Provide if empty). All of them share one constructor run and one cleanup.
+//
+//	func() (struct {
+//		dix.Out
+//		Server *http.Server
+//		Health *HealthChecker `di:"key:health"`
+//	}, func(), error) { ... }
+type Out struct{}
+
+var inType = reflect.TypeOf(In{})
+var outType = reflect.TypeOf(Out{})
+
+// isInStruct reports whether t is a struct embedding In.
+func isInStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type == inType {
+			return true
+		}
+	}
+	return false
+}
+
+// isOutStruct reports whether t is a struct embedding Out.
+func isOutStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type == outType {
+			return true
+		}
+	}
+	return false
+}
@@ -2,7 +2,9 @@ package dix
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"time"
 )
 
 func AddProvider[T any](key ProviderKey, value func() (T, error), opts ...ProviderAddOption) error {
@@ -19,6 +21,37 @@ func AddCtxProvider[T any](key ProviderKey, valueWithCtx func(context.Context) (
 	return addProvider(key, nil, valueWithCtx, opts...)
 }
 
+// AddStruct adds a provider building a zero T, or a new struct T points to, with its fields injected
+// by InjectStructWithCtx with the ctx of the provider. PostInject is run once injection succeeds.
+func AddStruct[T any](key ProviderKey, opts ...ProviderAddOption) error {
+	if !key.IsValid() {
+		return ErrInvalidKey
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if !isStructOrStructPtr(t) {
+		return ErrInjectStructMustBePointerStruct
+	}
+
+	// handle options
+	var opt providerAddOption
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	tmp := newCtxContainerProvider(
+		func(ctx context.Context) (any, error) {
+			return newInjectedStruct(ctx, t)
+		},
+		opt.noCache,
+		opt.priority,
+		opt.tagMap,
+	)
+	tmp.isPostInjected = true
+
+	return addContainerProvider(t, key, tmp, opt)
+}
+
 func addProvider[T any](key ProviderKey, value func() (T, error), valueWithCtx func(context.Context) (T, error), opts ...ProviderAddOption) error {
 	if !key.IsValid() {
 		return ErrInvalidKey
@@ -39,6 +72,7 @@ func addProvider[T any](key ProviderKey, value func() (T, error), valueWithCtx f
 				return value()
 			},
 			opt.noCache,
+			opt.priority,
 			opt.tagMap,
 		)
 	}
@@ -48,42 +82,65 @@ func addProvider[T any](key ProviderKey, value func() (T, error), valueWithCtx f
 				return valueWithCtx(ctx)
 			},
 			opt.noCache,
+			opt.priority,
 			opt.tagMap,
 		)
 	}
 
+	return addContainerProvider(t, key, tmp, opt)
+}
+
+func addContainerProvider(t reflect.Type, key ProviderKey, tmp *containerProvider, opt providerAddOption) error {
+	keys, err := checkContainerProvider(t, key, tmp, opt)
+	if err != nil {
+		return err
+	}
+	setContainerProvider(t, key, keys, tmp)
+	return nil
+}
+
+// checkContainerProvider runs the BeforeDuplicateRegister hooks of adding tmp, and returns the keys to set it under.
+func checkContainerProvider(t reflect.Type, key ProviderKey, tmp *containerProvider, opt providerAddOption) ([]ProviderKey, error) {
 	oldValue, _ := getContainerNestedMapValue(Container.typeKeyProviderMap, t, key)
-	if oldValue != nil && Container.beforeDuplicateRegister != nil {
+	if oldValue != nil {
 		oldValue.mu.RLock()
-		err := Container.beforeDuplicateRegister(NewBeforeDuplicateRegisterCtx(t, nil, nil, nil, &key, oldValue, tmp, false))
+		ctx := NewBeforeDuplicateRegisterCtx(t, nil, nil, nil, &key, oldValue, tmp, false)
 		oldValue.mu.RUnlock()
+		err := Container.beforeDuplicateRegister.runUntilErr(func(f BeforeDuplicateRegisterFunc) error { return f(ctx) })
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
+	keys := []ProviderKey{key}
 	if opt.setDefault {
 		oldValue, _ := getContainerNestedMapValue(Container.typeKeyProviderMap, t, DefaultProviderKey)
-		if oldValue != nil && Container.beforeDuplicateRegister != nil {
+		if oldValue != nil {
 			oldValue.mu.RLock()
-			err := Container.beforeDuplicateRegister(NewBeforeDuplicateRegisterCtx(t, nil, nil, nil, &key, oldValue, tmp, true))
+			ctx := NewBeforeDuplicateRegisterCtx(t, nil, nil, nil, &key, oldValue, tmp, true)
 			oldValue.mu.RUnlock()
+			err := Container.beforeDuplicateRegister.runUntilErr(func(f BeforeDuplicateRegisterFunc) error { return f(ctx) })
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 
-		setValueToContainerNestedMap(Container.typeKeyProviderMap, t, DefaultProviderKey, tmp)
+		keys = append(keys, DefaultProviderKey)
 	}
+	return keys, nil
+}
 
-	// to avoid replace DefaultProviderKey value fail but key value get set
-	setValueToContainerNestedMap(Container.typeKeyProviderMap, t, key, tmp)
+// setContainerProvider sets tmp under every key at once, then runs the AfterAdd hooks.
+func setContainerProvider(t reflect.Type, key ProviderKey, keys []ProviderKey, tmp *containerProvider) {
+	setValuesToContainerNestedMap(Container.typeKeyProviderMap, t, keys, tmp)
 
-	if Container.afterAdd != nil {
-		Container.afterAdd(NewAfterAddCtx(t, nil, nil, &key, tmp))
+	if Container.afterAdd.has() {
+		// others may run it already
+		tmp.mu.RLock()
+		ctx := NewAfterAddCtx(t, nil, nil, &key, tmp)
+		tmp.mu.RUnlock()
+		Container.afterAdd.run(func(f AfterAddFunc) { f(ctx) })
 	}
-
-	return nil
 }
 
 func GetProvider[T any](opts ...ProviderGetOption) (T, error) {
@@ -128,35 +185,87 @@ func getProviderByTypeKey(ctx context.Context, t reflect.Type, key ProviderKey,
 		return nil, nil, err
 	}
 
-	provider.mu.Lock()
-	defer provider.mu.Unlock()
+	tmp, err := runProvider(ctx, t, &key, provider, opt.reload)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	if !opt.reload && provider.cacheValue != nil {
-		return provider, provider.cacheValue, nil
+	return provider, tmp, nil
+}
+
+// runProvider returns the cached value of provider, or runs its factory function if there is none or reload is set.
+// Hooks are called without holding provider.mu, so they may inspect the provider.
+// key is nil for group contributions, which are not under a provider key and run no provider hooks.
+func runProvider(ctx context.Context, t reflect.Type, key *ProviderKey, provider *containerProvider, reload bool) (any, error) {
+	if !reload {
+		if tmp, ok := providerCacheHit(t, key, provider); ok {
+			return tmp, nil
+		}
 	}
 
+	provider.runMu.Lock()
+	defer provider.runMu.Unlock()
+
+	// another run may have cached a value while waiting
+	if !reload {
+		if tmp, ok := providerCacheHit(t, key, provider); ok {
+			return tmp, nil
+		}
+	}
+
+	provider.mu.RLock()
+	tags := provider.GetTagMap()
+	provider.mu.RUnlock()
+
+	if key != nil {
+		Container.beforeProviderRun.run(func(f BeforeProviderRunFunc) { f(NewBeforeProviderRunCtx(t, *key, tags, reload)) })
+	}
+	start := time.Now()
+
+	var err error
+
 	done := make(chan struct{})
 	var tmp any
 
 	go func() {
-		if provider.isValueWithCtx {
-			tmp, err = provider.valueWithCtx(ctx)
-		} else {
+		runCtx, postInjected := withPostInjectTracker(ctx)
+		switch {
+		case provider.valueWithReload != nil:
+			tmp, err = provider.valueWithReload(runCtx, reload)
+		case provider.isValueWithCtx:
+			tmp, err = provider.valueWithCtx(runCtx)
+		default:
 			tmp, err = provider.value()
 		}
+		// the factory function may have injected its value with InjectStructWithCtx and its ctx already
+		if err == nil && !provider.isPostInjected && !postInjected.has(tmp) {
+			err = postInject(ctx, tmp)
+		}
 		close(done)
 	}()
 
 	select {
 	case <-ctx.Done():
-		return nil, nil, ctx.Err() // timeout, canceled
+		// timeout, canceled
+		if key != nil {
+			Container.providerRunFailed.run(func(f ProviderRunFailedFunc) {
+				f(NewProviderRunFailedCtx(t, *key, tags, time.Since(start), ctx.Err()))
+			})
+		}
+		return nil, ctx.Err()
 	case <-done:
 		// continue
 	}
 	if err != nil {
-		return nil, nil, err
+		if key != nil {
+			Container.providerRunFailed.run(func(f ProviderRunFailedFunc) {
+				f(NewProviderRunFailedCtx(t, *key, tags, time.Since(start), err))
+			})
+		}
+		return nil, err
 	}
 
+	provider.mu.Lock()
 	if !provider.noCache {
 		provider.cacheValue = tmp
 	}
@@ -167,14 +276,44 @@ func getProviderByTypeKey(ctx context.Context, t reflect.Type, key ProviderKey,
 		provider.setAccessed()
 	}
 
-	if Container.afterProviderRun != nil {
-		Container.afterProviderRun(NewAfterProviderRunCtx(t, key, provider, tmp))
+	if key == nil {
+		provider.mu.Unlock()
+		return tmp, nil
 	}
-	if isFirstAccess && Container.afterFirstAccess != nil {
-		Container.afterFirstAccess(NewAfterFirstAccessCtx(t, nil, nil, &key, provider))
+
+	// snapshots, taken under the lock
+	afterRunCtx := NewAfterProviderRunCtx(t, *key, provider, tmp)
+	var firstAccessCtx AfterFirstAccessCtx
+	if isFirstAccess {
+		firstAccessCtx = NewAfterFirstAccessCtx(t, nil, nil, key, provider)
 	}
+	provider.mu.Unlock()
 
-	return provider, tmp, nil
+	Container.afterProviderRun.run(func(f AfterProviderRunFunc) { f(afterRunCtx) })
+	if isFirstAccess {
+		Container.afterFirstAccess.run(func(f AfterFirstAccessFunc) { f(firstAccessCtx) })
+	}
+
+	return tmp, nil
+}
+
+// providerCacheHit returns the cached value of provider, if any, and runs the ProviderCacheHit hooks.
+func providerCacheHit(t reflect.Type, key *ProviderKey, provider *containerProvider) (any, bool) {
+	provider.mu.RLock()
+	tmp := provider.cacheValue
+	var tags map[string]any
+	if tmp != nil && key != nil && Container.providerCacheHit.has() {
+		tags = provider.GetTagMap()
+	}
+	provider.mu.RUnlock()
+
+	if tmp == nil {
+		return nil, false
+	}
+	if tags != nil {
+		Container.providerCacheHit.run(func(f ProviderCacheHitFunc) { f(NewProviderCacheHitCtx(t, *key, tags)) })
+	}
+	return tmp, true
 }
 
 func MustGetProvider[T any](opts ...ProviderGetOption) T {
@@ -201,69 +340,69 @@ func MustGetProviderByKeyWithCtx[T any](ctx context.Context, key ProviderKey, op
 	return v
 }
 
+// HasProvider reports whether a provider of T is registered under key, without running it.
+func HasProvider[T any](key ProviderKey) bool {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	_, err := getContainerNestedMapValue(Container.typeKeyProviderMap, t, key)
+	return err == nil
+}
+
 func ProviderExist[T any]() bool {
-	return ProviderExistByKey[T](DefaultProviderKey)
+	return HasProvider[T](DefaultProviderKey)
 }
 
+// ProviderExistByKey reports whether the provider is registered, even if its factory function would fail.
 func ProviderExistByKey[T any](key ProviderKey) bool {
-	_, err := GetProviderByKey[T](key)
-	return err == nil
+	return HasProvider[T](key)
 }
 
-func DeleteProvider[T any]() {
-	DeleteProviderByKey[T](DefaultProviderKey)
+func DeleteProvider[T any]() error {
+	return DeleteProviderByKey[T](DefaultProviderKey)
 }
 
 func DeleteProviderByKey[T any](key ProviderKey) error {
 	t := reflect.TypeOf((*T)(nil)).Elem()
-	_, err := deleteContainerNestedMapValue(Container.typeKeyProviderMap, t, key)
+	// delete first so others won't see it
+	provider, err := deleteContainerNestedMapValue(Container.typeKeyProviderMap, t, key)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	if _, closeErr := closeContainerData(context.Background(), t, key, provider, false); closeErr != nil {
+		err = fmt.Errorf("failed at type=%v, key=%v: %w", t, key, closeErr)
+	}
+	provider.mu.RLock()
+	tags := provider.GetTagMap()
+	provider.mu.RUnlock()
+	Container.afterDelete.run(func(f AfterDeleteFunc) { f(NewAfterDeleteCtx(t, nil, &key, tags, err)) })
+	return err
 }
 
+// ListProviderKeys returns keys sorted by priority (higher first), then registration order.
 func ListProviderKeys[T any]() []ProviderKey {
 	t := reflect.TypeOf((*T)(nil)).Elem()
-	keyProviderMap, exist := Container.typeKeyProviderMap.Get(t)
-	if !exist {
-		return []ProviderKey{}
-	}
-	keys := make([]ProviderKey, 0, keyProviderMap.Size())
-	keyProviderMap.Range(func(key ProviderKey, val *containerProvider) bool {
-		if key == DefaultProviderKey {
-			return true
-		}
-
-		keys = append(keys, key)
-		return true
-	})
+	keys, _ := listSorted(Container.typeKeyProviderMap, t, DefaultProviderKey)
 	return keys
 }
 
+// GetAllProvider returns values sorted by priority (higher first), then registration order.
+// It stops at the first provider whose factory function fails, and returns its error.
 func GetAllProvider[T any](opts ...ProviderGetOption) ([]T, error) {
-	t := reflect.TypeOf((*T)(nil)).Elem()
-	keyProviderMap, exist := Container.typeKeyProviderMap.Get(t)
-	if !exist {
-		return []T{}, nil
+	// handle options
+	var opt providerGetOption
+	for _, o := range opts {
+		o(&opt)
 	}
-	var err error
-	values := make([]T, 0, keyProviderMap.Size())
-	keyProviderMap.Range(func(key ProviderKey, val *containerProvider) bool {
-		if key == DefaultProviderKey {
-			return true
-		}
 
-		var tmp T
-		tmp, err = GetProviderByKey[T](key, opts...)
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	keys, providers := listSorted(Container.typeKeyProviderMap, t, DefaultProviderKey)
+	values := make([]T, 0, len(providers))
+	for i, provider := range providers {
+		tmp, err := runProvider(context.Background(), t, &keys[i], provider, opt.reload)
 		if err != nil {
-			return false
+			return []T{}, fmt.Errorf("failed at type=%v, key=%v: %w", t, keys[i], err)
 		}
-		values = append(values, tmp)
-		return true
-	})
-	if err != nil {
-		return []T{}, nil
+		values = append(values, tmp.(T))
 	}
 	return values, nil
 }
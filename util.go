@@ -1,7 +1,14 @@
 package dix
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"reflect"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/jbterrylin/dix/internal/mapx"
 )
@@ -25,16 +32,17 @@ func getContainerNestedMapValue[Key ~string, Value any](
 	return val, nil
 }
 
-func setValueToContainerNestedMap[Key ~string, Value any](
+// setValuesToContainerNestedMap sets value under every key at once, so readers never see only some of them replaced.
+func setValuesToContainerNestedMap[Key ~string, Value any](
 	typeKeyValueMap *mapx.SafeMap[reflect.Type, *mapx.SafeMap[Key, Value]],
 	t reflect.Type,
-	key Key,
+	keys []Key,
 	value Value,
 ) {
 	keyValueMap, _ := typeKeyValueMap.GetOrSet(t, func() *mapx.SafeMap[Key, Value] {
 		return mapx.NewSafeMap[Key, Value]()
 	})
-	keyValueMap.Set(key, value)
+	keyValueMap.SetMany(keys, value)
 }
 
 func deleteContainerNestedMapValue[Key ~string, Value any](
@@ -73,6 +81,57 @@ func getTypeKeysMap[Key ~string, Value iContainerData](
 	return typeKeysMap
 }
 
+type ordered interface {
+	order() (priority int, seq uint64)
+}
+
+// orderLess sorts by priority (higher first), then registration order.
+func orderLess(a, b ordered) bool {
+	aPriority, aSeq := a.order()
+	bPriority, bSeq := b.order()
+	if aPriority != bPriority {
+		return aPriority > bPriority
+	}
+	return aSeq < bSeq
+}
+
+// listSorted returns every key and value of t except defaultKey, sorted by orderLess.
+func listSorted[Key ~string, Value iContainerData](
+	typeKeyValueMap *mapx.SafeMap[reflect.Type, *mapx.SafeMap[Key, Value]],
+	t reflect.Type,
+	defaultKey Key,
+) ([]Key, []Value) {
+	keyValueMap, exist := typeKeyValueMap.Get(t)
+	if !exist {
+		return []Key{}, []Value{}
+	}
+
+	type entry struct {
+		key   Key
+		value Value
+	}
+	entries := make([]entry, 0, keyValueMap.Size())
+	keyValueMap.Range(func(key Key, val Value) bool {
+		if key == defaultKey {
+			return true
+		}
+
+		entries = append(entries, entry{key: key, value: val})
+		return true
+	})
+	sort.SliceStable(entries, func(i, j int) bool {
+		return orderLess(entries[i].value, entries[j].value)
+	})
+
+	keys := make([]Key, 0, len(entries))
+	values := make([]Value, 0, len(entries))
+	for _, e := range entries {
+		keys = append(keys, e.key)
+		values = append(values, e.value)
+	}
+	return keys, values
+}
+
 func copyMap[Key comparable, Value any](tmp map[Key]Value) map[Key]Value {
 	copyMap := make(map[Key]Value, len(tmp))
 	for k, v := range tmp {
@@ -80,3 +139,157 @@ func copyMap[Key comparable, Value any](tmp map[Key]Value) map[Key]Value {
 	}
 	return copyMap
 }
+
+type ctxCloser interface {
+	Close(ctx context.Context) error
+}
+
+// closerOnCloseHook returns a hook closing val if it implements Close(ctx) error or io.Closer.
+func closerOnCloseHook(val any) func(context.Context) error {
+	switch closer := val.(type) {
+	case ctxCloser:
+		return closer.Close
+	case io.Closer:
+		return func(context.Context) error {
+			return closer.Close()
+		}
+	}
+	return nil
+}
+
+// closeContainerData runs the close hooks of val between the BeforeClose and AfterClose hooks, if it has any to run.
+// It takes the lock of val only to claim the close, so the hooks can inspect val.
+func closeContainerData[Key ~string](ctx context.Context, t reflect.Type, key Key, val iContainerData, forceClose bool) (refErr error, closeErr error) {
+	val.lock()
+	closeFunc := val.closer()
+	tags := val.GetTagMap()
+	val.unlock()
+	if closeFunc == nil {
+		return nil, nil
+	}
+
+	valueKey, providerKey := eventKeys(key)
+	Container.beforeClose.run(func(f BeforeCloseFunc) { f(NewBeforeCloseCtx(t, valueKey, providerKey, tags)) })
+	start := time.Now()
+	refErr, closeErr = closeFunc(ctx, forceClose)
+	Container.afterClose.run(func(f AfterCloseFunc) {
+		f(NewAfterCloseCtx(t, valueKey, providerKey, tags, time.Since(start), errors.Join(refErr, closeErr)))
+	})
+	return refErr, closeErr
+}
+
+// eventKeys returns key as the ValueKey or ProviderKey of a hook ctx.
+func eventKeys[Key ~string](key Key) (*ValueKey, *ProviderKey) {
+	switch tmp := any(key).(type) {
+	case ValueKey:
+		return &tmp, nil
+	case ProviderKey:
+		return nil, &tmp
+	}
+	return nil, nil
+}
+
+// runCloseHooks runs the PreClose method of val, if any, then hook. hook runs even if PreClose fails.
+func runCloseHooks(ctx context.Context, val any, hook func(context.Context) error) error {
+	var errs []error
+	if preCloser, ok := val.(PreCloser); ok && !isNilPtr(val) {
+		if err := runOnCloseHook(ctx, preCloser.PreClose); err != nil {
+			errs = append(errs, fmt.Errorf("failed at pre close, type=%T: %w", val, err))
+		}
+	}
+	if hook != nil {
+		if err := runOnCloseHook(ctx, hook); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// postInject runs the PostInject method of val, if any.
+func postInject(ctx context.Context, val any) error {
+	postInjector, ok := val.(PostInjector)
+	if !ok || isNilPtr(val) {
+		return nil
+	}
+	if err := postInjector.PostInject(ctx); err != nil {
+		return fmt.Errorf("failed at post inject, type=%T: %w", val, err)
+	}
+	return nil
+}
+
+// postInjectTracker remembers the targets InjectStructWithCtx ran PostInject on during one run of a factory function,
+// so runProvider does not run it again on the value returned by a factory like
+// func(ctx context.Context) (*S, error) { s := &S{}; return s, dix.InjectStructWithCtx(ctx, s) }.
+// It is passed to the factory function through its ctx.
+type postInjectTracker struct {
+	mu   sync.Mutex
+	done map[any]struct{}
+}
+
+type postInjectTrackerKey struct{}
+
+// withPostInjectTracker returns ctx with a new tracker, for one run of a factory function.
+func withPostInjectTracker(ctx context.Context) (context.Context, *postInjectTracker) {
+	t := &postInjectTracker{done: make(map[any]struct{})}
+	return context.WithValue(ctx, postInjectTrackerKey{}, t), t
+}
+
+// addPostInjected records that PostInject ran on target, a pointer, if ctx is the ctx of a factory function run.
+func addPostInjected(ctx context.Context, target any) {
+	t, ok := ctx.Value(postInjectTrackerKey{}).(*postInjectTracker)
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.done[target] = struct{}{}
+}
+
+// has reports whether PostInject already ran on val.
+func (t *postInjectTracker) has(val any) bool {
+	// only pointers are recorded, and other values may not be comparable
+	if reflect.ValueOf(val).Kind() != reflect.Ptr {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.done[val]
+	return ok
+}
+
+func isNilPtr(val any) bool {
+	v := reflect.ValueOf(val)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// runOnCloseHook runs hook with panic recovery, and stops waiting for it once ctx ends.
+// hook is not started if ctx has already ended.
+func runOnCloseHook(ctx context.Context, hook func(context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("%w: %v", ErrOnCloseHookPanic, r)
+			}
+		}()
+		done <- hook(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		// both may be ready, and the result of a finished hook is not dropped
+		select {
+		case err := <-done:
+			return err
+		default:
+			return ctx.Err() // timeout, canceled
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package dix
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"reflect"
 )
 
@@ -17,37 +20,58 @@ func Add[T any](key ValueKey, val T, opts ...ValueAddOption) error {
 
 	t := reflect.TypeOf((*T)(nil)).Elem()
 
-	tmp := newContainerValue(val, opt.onCloseHook, opt.tagMap)
+	onCloseHook := opt.onCloseHook
+	if onCloseHook == nil && !opt.skipAutoClose {
+		onCloseHook = closerOnCloseHook(val)
+	}
+
+	tmp := newContainerValue(val, onCloseHook, opt.priority, opt.tagMap)
 
 	oldValue, _ := getContainerNestedMapValue(Container.typeKeyValueMap, t, key)
-	if oldValue != nil && Container.beforeDuplicateRegister != nil {
+	if oldValue != nil {
 		oldValue.mu.RLock()
-		err := Container.beforeDuplicateRegister(NewBeforeDuplicateRegisterCtx(t, &key, oldValue, tmp, nil, nil, nil, false))
+		ctx := NewBeforeDuplicateRegisterCtx(t, &key, oldValue, tmp, nil, nil, nil, false)
 		oldValue.mu.RUnlock()
+		err := Container.beforeDuplicateRegister.runUntilErr(func(f BeforeDuplicateRegisterFunc) error { return f(ctx) })
 		if err != nil {
 			return err
 		}
 	}
 
+	keys := []ValueKey{key}
+	var oldDefaultValue *containerValue
 	if opt.setDefault {
-		oldValue, _ := getContainerNestedMapValue(Container.typeKeyValueMap, t, DefaultValueKey)
-		if oldValue != nil && Container.beforeDuplicateRegister != nil {
-			oldValue.mu.RLock()
-			err := Container.beforeDuplicateRegister(NewBeforeDuplicateRegisterCtx(t, &key, oldValue, tmp, nil, nil, nil, true))
-			oldValue.mu.RUnlock()
+		oldDefaultValue, _ = getContainerNestedMapValue(Container.typeKeyValueMap, t, DefaultValueKey)
+		if oldDefaultValue != nil {
+			oldDefaultValue.mu.RLock()
+			ctx := NewBeforeDuplicateRegisterCtx(t, &key, oldDefaultValue, tmp, nil, nil, nil, true)
+			oldDefaultValue.mu.RUnlock()
+			err := Container.beforeDuplicateRegister.runUntilErr(func(f BeforeDuplicateRegisterFunc) error { return f(ctx) })
 			if err != nil {
 				return err
 			}
 		}
 
-		setValueToContainerNestedMap(Container.typeKeyValueMap, t, DefaultValueKey, tmp)
+		keys = append(keys, DefaultValueKey)
 	}
 
-	// to avoid replace DefaultValueKey value fail but key value get set
-	setValueToContainerNestedMap(Container.typeKeyValueMap, t, key, tmp)
+	// set once every hook passed, and both keys at once
+	setValuesToContainerNestedMap(Container.typeKeyValueMap, t, keys, tmp)
 
-	if Container.afterAdd != nil {
-		Container.afterAdd(NewAfterAddCtx(t, &key, tmp, nil, nil))
+	if Container.afterAdd.has() {
+		// others may access it already
+		tmp.mu.RLock()
+		ctx := NewAfterAddCtx(t, &key, tmp, nil, nil)
+		tmp.mu.RUnlock()
+		Container.afterAdd.run(func(f AfterAddFunc) { f(ctx) })
+	}
+
+	if oldValue != nil {
+		Container.afterReplace.run(func(f AfterReplaceFunc) { f(NewAfterReplaceCtx(t, key, oldValue.value, val)) })
+	}
+	// the default key usually shares the old value of key
+	if oldDefaultValue != nil && oldDefaultValue != oldValue {
+		Container.afterReplace.run(func(f AfterReplaceFunc) { f(NewAfterReplaceCtx(t, DefaultValueKey, oldDefaultValue.value, val)) })
 	}
 
 	return nil
@@ -76,20 +100,17 @@ func getByTypeKey(t reflect.Type, key ValueKey) (*containerValue, error) {
 	}
 
 	val.mu.Lock()
-	defer val.mu.Unlock()
-
+	val.refCounterIncr()
 	if val.isAccessed {
-		val.refCounterIncr()
+		val.mu.Unlock()
 		return val, nil
 	}
-
 	val.setAccessed()
+	// snapshot taken under the lock, hooks run without it
+	ctx := NewAfterFirstAccessCtx(t, &key, val, nil, nil)
+	val.mu.Unlock()
 
-	if Container.afterFirstAccess != nil {
-		Container.afterFirstAccess(NewAfterFirstAccessCtx(t, &key, val, nil, nil))
-	}
-
-	val.refCounterIncr()
+	Container.afterFirstAccess.run(func(f AfterFirstAccessFunc) { f(ctx) })
 	return val, nil
 }
 
@@ -105,25 +126,38 @@ func MustGetByKey[T any](key ValueKey) T {
 	return v
 }
 
+// Has reports whether a value of T is registered under key.
+// Unlike GetByKey, it does not mark the value accessed, trigger hooks or touch the ref counter.
+func Has[T any](key ValueKey) bool {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	_, err := getContainerNestedMapValue(Container.typeKeyValueMap, t, key)
+	return err == nil
+}
+
 func Exist[T any]() bool {
-	return ExistByKey[T](DefaultValueKey)
+	return Has[T](DefaultValueKey)
 }
 
 func ExistByKey[T any](key ValueKey) bool {
-	_, err := GetByKey[T](key)
-	return err == nil
+	return Has[T](key)
 }
 
-func Delete[T any](opts ...ValueDeleteOption) {
-	DeleteByKey[T](DefaultValueKey, opts...)
+func Delete[T any](opts ...ValueDeleteOption) error {
+	return DeleteByKey[T](DefaultValueKey, opts...)
 }
 
 func DeleteByKey[T any](key ValueKey, opts ...ValueDeleteOption) error {
+	return DeleteByKeyWithCtx[T](context.Background(), key, opts...)
+}
+
+// When safe delete is enabled, waiting for the ref counter stops once ctx ends.
+// The OnCloseHook is then skipped unless WithValueForceClose is set, and a *RefCountTimeoutError is returned.
+func DeleteByKeyWithCtx[T any](ctx context.Context, key ValueKey, opts ...ValueDeleteOption) error {
 	t := reflect.TypeOf((*T)(nil)).Elem()
-	return deleteByTypeKey(t, key, opts...)
+	return deleteByTypeKey(ctx, t, key, opts...)
 }
 
-func deleteByTypeKey(t reflect.Type, key ValueKey, opts ...ValueDeleteOption) error {
+func deleteByTypeKey(ctx context.Context, t reflect.Type, key ValueKey, opts ...ValueDeleteOption) error {
 	// handle options
 	var opt valueDeleteOption
 	for _, o := range opts {
@@ -135,47 +169,42 @@ func deleteByTypeKey(t reflect.Type, key ValueKey, opts ...ValueDeleteOption) er
 	if err != nil {
 		return err
 	}
-	value.mu.Lock()
-	defer value.mu.Unlock()
-	if !opt.skipOnClose {
-		value.triggerOnCloseHook()
+	value.mu.RLock()
+	tags := value.GetTagMap()
+	value.mu.RUnlock()
+	if opt.skipOnClose {
+		Container.afterDelete.run(func(f AfterDeleteFunc) { f(NewAfterDeleteCtx(t, &key, nil, tags, nil)) })
+		return nil
 	}
-	return nil
+
+	var errs []error
+	refErr, closeErr := closeContainerData(ctx, t, key, value, opt.forceClose)
+	if refErr != nil {
+		errs = append(errs, newRefCountTimeoutError(refErr, RefCountLeak{Type: t, Key: key, RefCount: value.GetRefCounter()}))
+	}
+	if closeErr != nil {
+		errs = append(errs, fmt.Errorf("failed at type=%v, key=%v: %w", t, key, closeErr))
+	}
+	err = errors.Join(errs...)
+	Container.afterDelete.run(func(f AfterDeleteFunc) { f(NewAfterDeleteCtx(t, &key, nil, tags, err)) })
+	return err
 }
 
+// ListKeys returns keys sorted by priority (higher first), then registration order.
 func ListKeys[T any]() []ValueKey {
 	t := reflect.TypeOf((*T)(nil)).Elem()
-	keyValueMap, exist := Container.typeKeyValueMap.Get(t)
-	if !exist {
-		return []ValueKey{}
-	}
-	keys := make([]ValueKey, 0, keyValueMap.Size())
-	keyValueMap.Range(func(key ValueKey, val *containerValue) bool {
-		if key == DefaultValueKey {
-			return true
-		}
-
-		keys = append(keys, key)
-		return true
-	})
+	keys, _ := listSorted(Container.typeKeyValueMap, t, DefaultValueKey)
 	return keys
 }
 
+// GetAll returns values sorted by priority (higher first), then registration order.
 func GetAll[T any]() []T {
 	t := reflect.TypeOf((*T)(nil)).Elem()
-	keyValueMap, exist := Container.typeKeyValueMap.Get(t)
-	if !exist {
-		return []T{}
-	}
-	values := make([]T, 0, keyValueMap.Size())
-	keyValueMap.Range(func(key ValueKey, val *containerValue) bool {
-		if key == DefaultValueKey {
-			return true
-		}
-
+	_, vals := listSorted(Container.typeKeyValueMap, t, DefaultValueKey)
+	values := make([]T, 0, len(vals))
+	for _, val := range vals {
 		values = append(values, val.value.(T))
-		return true
-	})
+	}
 	return values
 }
 
@@ -0,0 +1,159 @@
+package dix
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// watcher delivers the latest instance of a binding to a channel of size 1.
+type watcher[T any] struct {
+	ch   chan T
+	done chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+	last   any // the last *containerValue sent, to skip duplicates
+	unsubs []Unsubscribe
+}
+
+func newWatcher[T any]() *watcher[T] {
+	return &watcher[T]{
+		ch:   make(chan T, 1),
+		done: make(chan struct{}),
+	}
+}
+
+// send replaces any instance not received yet with v, so it never blocks.
+// If from is not nil, v is skipped when it was already sent from it.
+func (w *watcher[T]) send(from any, v T) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed || (from != nil && from == w.last) {
+		return
+	}
+	if from != nil {
+		w.last = from
+	}
+	select {
+	case <-w.ch:
+	default:
+	}
+	w.ch <- v
+}
+
+func (w *watcher[T]) close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.ch)
+	close(w.done)
+	unsubs := w.unsubs
+	w.unsubs = nil
+	w.mu.Unlock()
+
+	for _, unsub := range unsubs {
+		unsub()
+	}
+}
+
+// start keeps the unsubscribes of the hooks of w, and closes w once ctx ends.
+func (w *watcher[T]) start(ctx context.Context, unsubs ...Unsubscribe) {
+	w.mu.Lock()
+	if w.closed {
+		// closed by a hook before start
+		w.mu.Unlock()
+		for _, unsub := range unsubs {
+			unsub()
+		}
+		return
+	}
+	w.unsubs = unsubs
+	w.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			w.close()
+		case <-w.done:
+		}
+	}()
+}
+
+// Watch returns a channel receiving the value of T under key, then every value Add registers under key.
+// The channel has a size of 1 and only keeps the latest value not received yet, so a slow receiver skips
+// intermediate values instead of blocking Add. It is closed once ctx ends, the value is deleted, or the container is reset.
+// If no value is registered yet, the first one added is received.
+func Watch[T any](ctx context.Context, key ValueKey) <-chan T {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	w := newWatcher[T]()
+
+	// the current value of key, which may be shared with another key
+	sendCurrent := func() {
+		if val, err := getContainerNestedMapValue(Container.typeKeyValueMap, t, key); err == nil {
+			w.send(val, typed[T](val.value))
+		}
+	}
+
+	// subscribe first, so no value is missed between reading the current one and subscribing
+	w.start(ctx,
+		Container.afterAdd.addInternal(func(ctx AfterAddCtx) {
+			if ctx.Type == t && ctx.ValueKey != nil {
+				sendCurrent()
+			}
+		}),
+		Container.afterDelete.addInternal(func(ctx AfterDeleteCtx) {
+			if ctx.Type == t && ctx.ValueKey != nil && *ctx.ValueKey == key {
+				w.close()
+			}
+		}),
+		Container.afterReset.addInternal(func(ctx AfterResetCtx) {
+			w.close()
+		}),
+	)
+	sendCurrent()
+	return w.ch
+}
+
+// WatchProvider returns a channel receiving the cached value of the provider of T under key, if any,
+// then the value of every run of its factory function, including reloads and no-cache runs.
+// Delivery and closing work like Watch. A provider replaced by AddProvider is received once it runs.
+func WatchProvider[T any](ctx context.Context, key ProviderKey) <-chan T {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	w := newWatcher[T]()
+
+	w.start(ctx,
+		Container.afterProviderRun.addInternal(func(ctx AfterProviderRunCtx) {
+			if ctx.Type != t {
+				return
+			}
+			// the provider of key, which may be run through another key
+			provider, err := getContainerNestedMapValue(Container.typeKeyProviderMap, t, key)
+			if err == nil && provider == ctx.ContainerProvider {
+				w.send(nil, typed[T](ctx.Value))
+			}
+		}),
+		Container.afterDelete.addInternal(func(ctx AfterDeleteCtx) {
+			if ctx.Type == t && ctx.ProviderKey != nil && *ctx.ProviderKey == key {
+				w.close()
+			}
+		}),
+		Container.afterReset.addInternal(func(ctx AfterResetCtx) {
+			w.close()
+		}),
+	)
+
+	if provider, err := getContainerNestedMapValue(Container.typeKeyProviderMap, t, key); err == nil {
+		provider.mu.RLock()
+		cacheValue := provider.cacheValue
+		provider.mu.RUnlock()
+		if cacheValue != nil {
+			w.send(nil, typed[T](cacheValue))
+		}
+	}
+	return w.ch
+}